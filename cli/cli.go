@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/config"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/caiovfernandes/terragrunt-runner/ui"
+	"github.com/mattn/go-isatty"
+)
+
+// Version is set at build time with
+// -ldflags "-X github.com/caiovfernandes/terragrunt-runner/cli.Version=v1.2.3".
+var Version = "dev"
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "tui", usage: "tui [flags] [root-directory]", summary: "Browse and run stacks interactively (default)", run: runTUI},
+		{name: "list", usage: "list [flags]", summary: "List the stacks of the workspace", run: runList},
+		{name: "run", usage: "run <command> [flags]", summary: "Run a terragrunt command on the matching stacks", run: runRun},
+		{name: "drift", usage: "drift [flags]", summary: "Plan every stack and report the ones that drifted", run: runDrift},
+		{name: "modules", usage: "modules [flags]", summary: "Report the module versions pinned by the stacks", run: runModules},
+		{name: "graph", usage: "graph [flags]", summary: "Export the dependency graph as DOT or Mermaid", run: runGraph},
+		{name: "inspect", usage: "inspect <path> [flags]", summary: "Show what is known about a single stack", run: runInspect},
+		{name: "version", usage: "version", summary: "Print the version", run: runVersion},
+	}
+}
+
+// exitError stops Execute with the given exit code. Its message, if any, has
+// already been reported to the user.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// Execute runs the command line given in args, without the program name, and
+// returns the process exit code. Without a subcommand the interactive UI is
+// started, and a leading directory is taken as its root for compatibility
+// with earlier releases.
+func Execute(args []string) int {
+	name := "tui"
+	if len(args) > 0 {
+		switch {
+		case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+			usage()
+			return 0
+		case !strings.HasPrefix(args[0], "-") && lookup(args[0]) != nil:
+			name, args = args[0], args[1:]
+		}
+	}
+
+	err := lookup(name).run(args)
+	var exit exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return exit.code
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	return 1
+}
+
+func lookup(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: terragrunt-runner <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'terragrunt-runner <command> --help' for the flags of a command.")
+}
+
+// globalFlags are the flags shared by every command that loads a workspace.
+type globalFlags struct {
+	root   string
+	config string
+	output string
+	// formats are the accepted values of --output, text and json by default.
+	formats []string
+}
+
+func newFlagSet(name string, g *globalFlags) *flag.FlagSet {
+	c := lookup(name)
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: terragrunt-runner %s\n\n%s\n\nFlags:\n", c.usage, c.summary)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&g.root, "root", "", "root directory of the terragrunt repository")
+	flags.StringVar(&g.config, "config", "", "configuration file to use instead of "+config.FileName)
+	flags.StringVar(&g.output, "output", "text", "output format: text or json")
+	return flags
+}
+
+// parse parses flags that may appear before, between or after the positional
+// arguments, which it returns.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (g globalFlags) validate() error {
+	formats := g.formats
+	if len(formats) == 0 {
+		formats = []string{"text", "json"}
+	}
+	for _, format := range formats {
+		if g.output == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q, expected %s", g.output, strings.Join(formats, ", "))
+}
+
+func (g globalFlags) load() (config.Config, terragrunt.Workspace, error) {
+	if err := g.validate(); err != nil {
+		return config.Config{}, terragrunt.Workspace{}, err
+	}
+	cfg, err := config.Load(config.Options{Root: g.root, File: g.config})
+	if err != nil {
+		return cfg, terragrunt.Workspace{}, err
+	}
+	workspace, diagnostics, err := terragrunt.Load(context.Background(), cfg.Root, cfg.LoadOptions()...)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, diagnostic)
+	}
+	return cfg, workspace, err
+}
+
+func writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func runTUI(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("tui", &g)
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+	if len(positional) == 1 {
+		g.root = positional[0]
+	}
+
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		return fmt.Errorf("the interactive UI needs a terminal, use 'run' in pipelines")
+	}
+	cfg, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	return ui.Start(cfg, workspace)
+}
+
+func runVersion(args []string) error {
+	fmt.Println(Version)
+	return nil
+}
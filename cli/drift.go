@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/caiovfernandes/terragrunt-runner/history"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+// driftReport is the drift status of a single stack.
+type driftReport struct {
+	Stack     string   `json:"stack"`
+	Path      string   `json:"path"`
+	Status    string   `json:"status"`
+	Changes   string   `json:"changes,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// runDrift plans every matching stack with -detailed-exitcode. It exits with 2
+// when a stack drifted and with 1 when a plan failed, so that pipelines can
+// tell the two apart.
+func runDrift(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("drift", &g)
+	flags.Lookup("output").Usage = "output format: text, json or markdown"
+	g.formats = []string{"text", "json", "markdown"}
+	filter := flags.String("filter", "", "filter expression, e.g. 'project=prod region=us-*' (default: every stack)")
+	parallelism := flags.Int("parallelism", 0, "maximum number of stacks to plan at the same time (default from configuration)")
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	cfg, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	if *parallelism > 0 {
+		cfg.Parallelism = *parallelism
+	}
+	matcher, err := terragrunt.ParseFilter(*filter, cfg.Layout)
+	if err != nil {
+		return err
+	}
+
+	var jobs []terragrunt.Job
+	names := make(map[string]string)
+	for _, file := range workspace.Files() {
+		if matcher.Match(file) {
+			jobs = append(jobs, terragrunt.Job{Path: file.Path, Command: cfg.CommandFor(file.ProjectID, terragrunt.Drift)})
+			names[file.Path] = file.ProjectID + "/" + file.RegionID + "/" + file.StackID
+		}
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no stacks match %q", *filter)
+	}
+
+	store, err := history.Open(cfg.History.Dir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Plans are not ordered by dependencies since they do not change anything.
+	// Their output is left out, only the outcome of each stack is logged.
+	var mu sync.Mutex
+	batch := terragrunt.Batch{
+		Jobs:        jobs,
+		Parallelism: cfg.Parallelism,
+		OnFinish: func(result terragrunt.JobResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", names[result.Job.Path], terragrunt.ClassifyDrift(result.Plan, result.Err))
+			if !result.Started.IsZero() {
+				if err := store.Save(history.NewRecord(result)); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+		},
+	}
+
+	var reports []driftReport
+	drifted, errored := 0, 0
+	for _, result := range batch.Run(ctx) {
+		status := terragrunt.ClassifyDrift(result.Plan, result.Err)
+		report := driftReport{
+			Stack:  names[result.Job.Path],
+			Path:   result.Job.Path,
+			Status: string(status),
+		}
+		if result.Plan != nil {
+			report.Changes = result.Plan.Badge()
+			for _, resource := range result.Plan.Resources {
+				report.Resources = append(report.Resources, resource.Address)
+			}
+		}
+		if result.Err != nil {
+			report.Error = result.Err.Error()
+		}
+		switch status {
+		case terragrunt.Drifted:
+			drifted++
+		case terragrunt.Errored:
+			errored++
+		}
+		reports = append(reports, report)
+	}
+
+	switch g.output {
+	case "json":
+		if err := writeJSON(reports); err != nil {
+			return err
+		}
+	case "markdown":
+		writeDriftMarkdown(os.Stdout, reports)
+	default:
+		writeDriftTable(os.Stdout, reports)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d in sync, %d drifted, %d errored\n", len(reports)-drifted-errored, drifted, errored)
+	switch {
+	case errored > 0:
+		return exitError{code: 1}
+	case drifted > 0:
+		return exitError{code: 2}
+	}
+	return nil
+}
+
+func writeDriftTable(out io.Writer, reports []driftReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STACK\tSTATUS\tCHANGES\tERROR")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Stack, r.Status, r.Changes, r.Error)
+	}
+	w.Flush()
+}
+
+func writeDriftMarkdown(out io.Writer, reports []driftReport) {
+	fmt.Fprintln(out, "### Drift")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "| Stack | Status | Changes | Resources | Error |")
+	fmt.Fprintln(out, "| --- | --- | --- | --- | --- |")
+	for _, r := range reports {
+		resources := make([]string, len(r.Resources))
+		for i, address := range r.Resources {
+			resources[i] = "`" + address + "`"
+		}
+		fmt.Fprintf(out, "| %s | %s | %s | %s | %s |\n", r.Stack, r.Status, r.Changes,
+			strings.Join(resources, "<br>"), strings.ReplaceAll(r.Error, "|", "\\|"))
+	}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+func runGraph(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("graph", &g)
+	format := flags.String("format", "dot", "graph format: dot or mermaid")
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	cfg, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	graph := workspace.Graph().Relative(cfg.Root)
+	switch *format {
+	case "dot":
+		return graph.WriteDOT(os.Stdout)
+	case "mermaid":
+		return graph.WriteMermaid(os.Stdout)
+	}
+	return fmt.Errorf("unknown format %q, expected dot or mermaid", *format)
+}
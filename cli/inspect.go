@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+func runInspect(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("inspect", &g)
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	_, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	file, ok := workspace.FindFile(positional[0])
+	if !ok {
+		return fmt.Errorf("no terragrunt file found at %s", positional[0])
+	}
+	s := newStack(file, workspace.Graph())
+
+	if g.output == "json" {
+		return writeJSON(struct {
+			stack
+			Config    *terragrunt.Config          `json:"config"`
+			Effective *terragrunt.EffectiveConfig `json:"effective"`
+			Content   string                      `json:"content"`
+		}{s, file.Config, file.Effective, file.Content()})
+	}
+
+	fmt.Printf("Path:    %s\n", s.Path)
+	fmt.Printf("Project: %s\n", s.Project)
+	fmt.Printf("Region:  %s\n", s.Region)
+	fmt.Printf("Stack:   %s\n", s.Stack)
+	if len(s.Segments) > 0 {
+		names := make([]string, 0, len(s.Segments))
+		for name := range s.Segments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println("Segments:")
+		for _, name := range names {
+			fmt.Printf("  %s = %s\n", name, s.Segments[name])
+		}
+	}
+	printList("Dependencies", s.Dependencies)
+	printList("Dependents", s.Dependents)
+	if file.Effective != nil {
+		printList("Merged files", file.Effective.Files)
+		fmt.Println("Effective configuration:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, setting := range file.Effective.Settings() {
+			fmt.Fprintf(w, "  %s\t= %s\t(%s)\n", setting.Name, terragrunt.FormatValue(setting.Value), setting.Origin)
+		}
+		w.Flush()
+	}
+	fmt.Printf("\n%s\n", strings.TrimRight(file.Content(), "\n"))
+	return nil
+}
+
+func printList(title string, values []string) {
+	fmt.Printf("%s:\n", title)
+	if len(values) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, value := range values {
+		fmt.Printf("  %s\n", value)
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+// stack is the JSON representation of a terragrunt file.
+type stack struct {
+	Path         string            `json:"path"`
+	Project      string            `json:"project"`
+	Region       string            `json:"region"`
+	Stack        string            `json:"stack"`
+	Segments     map[string]string `json:"segments,omitempty"`
+	Dependencies []string          `json:"dependencies"`
+	Dependents   []string          `json:"dependents"`
+}
+
+func newStack(file terragrunt.File, graph *terragrunt.Graph) stack {
+	unit := filepath.Dir(file.Path)
+	return stack{
+		Path:         file.Path,
+		Project:      file.ProjectID,
+		Region:       file.RegionID,
+		Stack:        file.StackID,
+		Segments:     file.Segments,
+		Dependencies: nonNil(graph.Dependencies[unit]),
+		Dependents:   nonNil(graph.Dependents[unit]),
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func runList(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("list", &g)
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	_, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	graph := workspace.Graph()
+	stacks := []stack{}
+	for _, file := range workspace.Files() {
+		stacks = append(stacks, newStack(file, graph))
+	}
+
+	if g.output == "json" {
+		return writeJSON(stacks)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tREGION\tSTACK\tPATH")
+	for _, s := range stacks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Project, s.Region, s.Stack, s.Path)
+	}
+	return w.Flush()
+}
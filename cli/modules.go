@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+func runModules(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("modules", &g)
+	flags.Lookup("output").Usage = "output format: text, json or csv"
+	g.formats = []string{"text", "json", "csv"}
+	behind := flags.Bool("behind", false, "only list the stacks that are behind the latest version of their module")
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	_, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	modules := workspace.Modules()
+	if *behind {
+		modules = behindOnly(modules)
+	}
+
+	switch g.output {
+	case "json":
+		if modules == nil {
+			modules = []terragrunt.Module{}
+		}
+		return writeJSON(modules)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"source", "stack", "path", "ref", "status", "latest", "most_common"})
+		for _, module := range modules {
+			for _, s := range module.Stacks {
+				w.Write([]string{module.Source, s.Stack, s.Path, s.Ref, s.Status, module.Latest, module.MostCommon})
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, module := range modules {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\n", module.Source)
+		fmt.Fprintf(w, "  latest: %s, most common: %s\n", orNone(module.Latest), orNone(module.MostCommon))
+		for _, s := range module.Stacks {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", s.Stack, orNone(s.Ref), s.Status)
+		}
+	}
+	return w.Flush()
+}
+
+// behindOnly keeps the stacks that are behind the latest version, and the
+// modules that have some.
+func behindOnly(modules []terragrunt.Module) []terragrunt.Module {
+	var filtered []terragrunt.Module
+	for _, module := range modules {
+		var stacks []terragrunt.ModuleStack
+		for _, s := range module.Stacks {
+			if s.Status == terragrunt.VersionBehind {
+				stacks = append(stacks, s)
+			}
+		}
+		if len(stacks) > 0 {
+			module.Stacks = stacks
+			filtered = append(filtered, module)
+		}
+	}
+	return filtered
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
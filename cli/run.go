@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/caiovfernandes/terragrunt-runner/config"
+	"github.com/caiovfernandes/terragrunt-runner/history"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+// runSummary is the outcome of a headless run of a single stack.
+type runSummary struct {
+	Stack    string  `json:"stack"`
+	Path     string  `json:"path"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration_seconds"`
+	ExitCode int     `json:"exit_code"`
+	Changes  string  `json:"changes,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	// Class is the known cause of the error, e.g. "state lock".
+	Class      string `json:"error_class,omitempty"`
+	StderrTail string `json:"stderr_tail,omitempty"`
+}
+
+func runRun(args []string) error {
+	var g globalFlags
+	flags := newFlagSet("run", &g)
+	filter := flags.String("filter", "", "filter expression, e.g. 'project=prod region=us-*' (default: every stack)")
+	yes := flags.Bool("yes", false, "allow destructive commands such as apply and destroy")
+	parallelism := flags.Int("parallelism", 0, "maximum number of stacks to run at the same time (default from configuration)")
+	summaryFile := flags.String("summary", "", "also write the summary as Markdown to this file, e.g. $GITHUB_STEP_SUMMARY")
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitError{code: 2}
+	}
+
+	cfg, workspace, err := g.load()
+	if err != nil {
+		return err
+	}
+	if *parallelism > 0 {
+		cfg.Parallelism = *parallelism
+	}
+	command, ok := findCommand(cfg, positional[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q", positional[0])
+	}
+	if command.Destructive && !*yes {
+		return fmt.Errorf("%s is destructive, pass --yes to run it", command)
+	}
+	matcher, err := terragrunt.ParseFilter(*filter, cfg.Layout)
+	if err != nil {
+		return err
+	}
+
+	var jobs []terragrunt.Job
+	names := make(map[string]string)
+	for _, file := range workspace.Files() {
+		if matcher.Match(file) {
+			jobs = append(jobs, terragrunt.Job{Path: file.Path, Command: cfg.CommandFor(file.ProjectID, command)})
+			names[file.Path] = file.ProjectID + "/" + file.RegionID + "/" + file.StackID
+		}
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no stacks match %q", *filter)
+	}
+
+	store, err := history.Open(cfg.History.Dir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Logs go to stderr when stdout carries the JSON summary.
+	logs := io.Writer(os.Stdout)
+	if g.output == "json" {
+		logs = os.Stderr
+	}
+
+	var mu sync.Mutex
+	batch := terragrunt.Batch{
+		Jobs:        jobs,
+		Parallelism: cfg.Parallelism,
+		Graph:       workspace.Graph(),
+		OnStart: func(job terragrunt.Job) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(logs, "[%s] %s\n", names[job.Path], job.Command)
+		},
+		OnOutput: func(job terragrunt.Job, line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(logs, "[%s] %s\n", names[job.Path], line)
+		},
+		OnFinish: func(result terragrunt.JobResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !result.Started.IsZero() {
+				if err := store.Save(history.NewRecord(result)); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+		},
+	}
+
+	var summaries []runSummary
+	failed := 0
+	for _, result := range batch.Run(ctx) {
+		summary := runSummary{
+			Stack:    names[result.Job.Path],
+			Path:     result.Job.Path,
+			Status:   result.Status.String(),
+			Duration: result.Finished.Sub(result.Started).Round(time.Millisecond).Seconds(),
+			ExitCode: result.ExitCode,
+			Class:    string(result.Class),
+		}
+		if result.Plan != nil {
+			summary.Changes = result.Plan.Badge()
+		}
+		if result.Err != nil {
+			summary.Error = result.Err.Error()
+			summary.StderrTail = result.StderrTail
+		}
+		if result.Status != terragrunt.Succeeded {
+			failed++
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if *summaryFile != "" {
+		if err := appendMarkdownSummary(*summaryFile, command, summaries); err != nil {
+			return err
+		}
+	}
+	if g.output == "json" {
+		if err := writeJSON(summaries); err != nil {
+			return err
+		}
+	} else {
+		writeSummaryTable(os.Stdout, summaries)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d stacks did not succeed\n", failed, len(summaries))
+		return exitError{code: 1}
+	}
+	return nil
+}
+
+// reason explains a failure in a few words, preferring its class over the
+// full error.
+func (s runSummary) reason() string {
+	if s.Class != "" {
+		return s.Class
+	}
+	return s.Error
+}
+
+func writeSummaryTable(out io.Writer, summaries []runSummary) {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STACK\tSTATUS\tEXIT\tDURATION\tCHANGES\tERROR")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1fs\t%s\t%s\n", s.Stack, s.Status, s.ExitCode, s.Duration, s.Changes, s.reason())
+	}
+	w.Flush()
+}
+
+// appendMarkdownSummary appends the summary to path, which CI systems such as
+// GitHub Actions render on the job page.
+func appendMarkdownSummary(path string, command terragrunt.Command, summaries []runSummary) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### `%s`\n\n", command)
+	b.WriteString("| Stack | Status | Exit | Duration | Changes | Error |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "| %s | %s | %d | %.1fs | %s | %s |\n", s.Stack, s.Status, s.ExitCode, s.Duration, s.Changes, strings.ReplaceAll(s.reason(), "|", "\\|"))
+	}
+	b.WriteString("\n")
+	_, err = f.WriteString(b.String())
+	return err
+}
+
+// findCommand looks a command up by its name or, for command profiles, by
+// its label.
+func findCommand(cfg config.Config, name string) (terragrunt.Command, bool) {
+	for _, command := range cfg.Commands() {
+		if command.Title() == name {
+			return command, true
+		}
+	}
+	return terragrunt.Command{}, false
+}
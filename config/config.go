@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/caiovfernandes/terragrunt-runner/history"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file looked up in the root
+// directory of the terragrunt repository.
+const FileName = ".terragrunt-vision.yaml"
+
+// Config is the merged configuration of the tool. Sources are applied in
+// increasing order of precedence: defaults, the user file, the repository
+// file, environment variables and finally command line flags. Files are
+// merged key by key, so a repository file only needs to set what it changes.
+type Config struct {
+	Root        string                   `yaml:"root"`
+	Layout      terragrunt.Layout        `yaml:"layout"`
+	Ordering    terragrunt.Ordering      `yaml:"ordering"`
+	Scan        ScanConfig               `yaml:"scan"`
+	Parallelism int                      `yaml:"parallelism"`
+	Terragrunt  TerragruntConfig         `yaml:"terragrunt"`
+	AWS         AWSConfig                `yaml:"aws"`
+	Projects    map[string]ProjectConfig `yaml:"projects"`
+	Profiles    []CommandProfile         `yaml:"profiles"`
+	Keybindings map[string][]string      `yaml:"keybindings"`
+	History     HistoryConfig            `yaml:"history"`
+	Filters     []Filter                 `yaml:"filters"`
+}
+
+// ScanConfig restricts the directories searched for terragrunt files.
+type ScanConfig struct {
+	// Include and Exclude are globs of directories relative to the root,
+	// where `**` matches any number of directories.
+	Include        []string `yaml:"include"`
+	Exclude        []string `yaml:"exclude"`
+	FollowSymlinks bool     `yaml:"follow_symlinks"`
+	// MaxDepth limits how many directories below the root are searched,
+	// without limit when zero.
+	MaxDepth int `yaml:"max_depth"`
+	// Cache keeps the parsed files in the state directory between runs.
+	Cache bool `yaml:"cache"`
+}
+
+// LoadOptions returns the options of terragrunt.Load for the configuration.
+// The scan cache is left out when the state directory cannot be located.
+func (c Config) LoadOptions() []terragrunt.Option {
+	opts := []terragrunt.Option{
+		terragrunt.WithLayout(c.Layout),
+		terragrunt.WithOrdering(c.Ordering),
+		terragrunt.WithInclude(c.Scan.Include...),
+		terragrunt.WithExclude(c.Scan.Exclude...),
+		terragrunt.WithFollowSymlinks(c.Scan.FollowSymlinks),
+		terragrunt.WithMaxDepth(c.Scan.MaxDepth),
+	}
+	if c.Scan.Cache {
+		if dir, err := history.StateDir(c.History.Dir); err == nil {
+			opts = append(opts, terragrunt.WithCache(filepath.Join(dir, "scan-cache")))
+		}
+	}
+	return opts
+}
+
+type TerragruntConfig struct {
+	// Args are appended to every terragrunt command.
+	Args []string `yaml:"args"`
+}
+
+type AWSConfig struct {
+	Profile string `yaml:"profile"`
+	Region  string `yaml:"region"`
+}
+
+type HistoryConfig struct {
+	// Dir holds the execution history and the scan cache,
+	// $XDG_STATE_HOME/terragrunt-vision by default.
+	Dir string `yaml:"dir"`
+}
+
+// ProjectConfig overrides settings for the stacks of a single project. Its AWS
+// settings take precedence over the global ones, including AWS_PROFILE and
+// AWS_REGION, since they are more specific.
+type ProjectConfig struct {
+	AWS  AWSConfig `yaml:"aws"`
+	Args []string  `yaml:"args"`
+}
+
+// CommandProfile is a named terragrunt command with preset arguments that is
+// offered next to the built-in commands, e.g. a plan with `-lock=false`.
+type CommandProfile struct {
+	Name        string   `yaml:"name"`
+	Command     string   `yaml:"command"`
+	Args        []string `yaml:"args"`
+	Env         []string `yaml:"env"`
+	Destructive bool     `yaml:"destructive"`
+}
+
+// Options are the values given on the command line.
+type Options struct {
+	// Root is the root directory of the terragrunt repository.
+	Root string
+	// File replaces the repository configuration file.
+	File string
+}
+
+func Default() Config {
+	return Config{
+		Layout:      terragrunt.DefaultLayout,
+		Scan:        ScanConfig{Cache: true},
+		Parallelism: terragrunt.DefaultParallelism,
+		Keybindings: DefaultKeybindings(),
+	}
+}
+
+// Load builds the configuration for opts. Missing configuration files are
+// ignored unless the file was requested explicitly.
+func Load(opts Options) (Config, error) {
+	cfg := Default()
+
+	if path, ok := userFile(); ok {
+		if err := cfg.merge(path, false); err != nil {
+			return cfg, err
+		}
+	}
+
+	root := firstNonEmpty(opts.Root, os.Getenv("TERRAGRUNT_VISION_ROOT"))
+	file := firstNonEmpty(opts.File, os.Getenv("TERRAGRUNT_VISION_CONFIG"))
+	explicit := file != ""
+	if !explicit {
+		file = filepath.Join(firstNonEmpty(root, cfg.Root, "."), FileName)
+	}
+	fileRoot := cfg.Root
+	cfg.Root = ""
+	if err := cfg.merge(file, explicit); err != nil {
+		return cfg, err
+	}
+	if cfg.Root != "" && !filepath.IsAbs(cfg.Root) {
+		cfg.Root = filepath.Join(filepath.Dir(file), cfg.Root)
+	}
+	cfg.Root = firstNonEmpty(root, cfg.Root, fileRoot, ".")
+
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if err := c.Layout.Validate(); err != nil {
+		return fmt.Errorf("invalid layout: %v", err)
+	}
+	if err := c.Ordering.Validate(); err != nil {
+		return fmt.Errorf("invalid ordering: %v", err)
+	}
+	if c.Parallelism < 1 {
+		return fmt.Errorf("parallelism must be at least 1, got %d", c.Parallelism)
+	}
+	for _, profile := range c.Profiles {
+		if profile.Name == "" || profile.Command == "" {
+			return fmt.Errorf("command profiles need a name and a command")
+		}
+	}
+	for _, filter := range c.Filters {
+		if filter.Name == "" {
+			return fmt.Errorf("filters need a name")
+		}
+	}
+	if err := validateKeybindings(c.Keybindings); err != nil {
+		return fmt.Errorf("invalid keybindings: %v", err)
+	}
+	return nil
+}
+
+// Commands returns the built-in terragrunt commands followed by the command
+// profiles.
+func (c Config) Commands() []terragrunt.Command {
+	commands := append([]terragrunt.Command{}, terragrunt.Commands...)
+	for _, profile := range c.Profiles {
+		commands = append(commands, terragrunt.Command{
+			Label:       profile.Name,
+			Name:        profile.Command,
+			Args:        profile.Args,
+			Env:         profile.Env,
+			Destructive: profile.Destructive,
+		})
+	}
+	return commands
+}
+
+// CommandFor returns command with the default arguments and the AWS settings
+// that apply to the stacks of project.
+func (c Config) CommandFor(project string, command terragrunt.Command) terragrunt.Command {
+	projectConfig := c.Projects[project]
+
+	args := append([]string{}, command.Args...)
+	args = append(args, c.Terragrunt.Args...)
+	command.Args = append(args, projectConfig.Args...)
+	command.AWSProfile = firstNonEmpty(projectConfig.AWS.Profile, c.AWS.Profile)
+	command.AWSRegion = firstNonEmpty(projectConfig.AWS.Region, c.AWS.Region)
+	return command
+}
+
+func (c *Config) merge(path string, required bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filters := c.Filters
+	c.Filters = nil
+	if err := yaml.Unmarshal(content, c); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	c.Filters = mergeFilters(filters, c.Filters)
+	return nil
+}
+
+func (c *Config) applyEnv() error {
+	if value := os.Getenv("TERRAGRUNT_VISION_PARALLELISM"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid TERRAGRUNT_VISION_PARALLELISM %q: %v", value, err)
+		}
+		c.Parallelism = n
+	}
+	c.AWS.Profile = firstNonEmpty(os.Getenv("AWS_PROFILE"), c.AWS.Profile)
+	c.AWS.Region = firstNonEmpty(os.Getenv("AWS_REGION"), c.AWS.Region)
+	return nil
+}
+
+// userFile returns the path of the user configuration file, following the XDG
+// base directory specification.
+func userFile() (string, bool) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "terragrunt-vision", "config.yaml"), true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
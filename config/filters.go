@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Filter is a named selection of stacks for the filter view of the UI. A stack
+// matches when each non-empty list holds its value exactly.
+type Filter struct {
+	Name     string   `yaml:"name"`
+	Projects []string `yaml:"projects,omitempty"`
+	Regions  []string `yaml:"regions,omitempty"`
+	Stacks   []string `yaml:"stacks,omitempty"`
+	// Statuses are last-run statuses such as "failed", or "not run".
+	Statuses []string `yaml:"statuses,omitempty"`
+}
+
+// IsEmpty reports whether the filter matches every stack.
+func (f Filter) IsEmpty() bool {
+	return len(f.Projects)+len(f.Regions)+len(f.Stacks)+len(f.Statuses) == 0
+}
+
+// AddFilter adds filter to the configuration, replacing the filter with the
+// same name.
+func (c *Config) AddFilter(filter Filter) {
+	c.Filters = replaceFilter(c.Filters, filter)
+}
+
+// mergeFilters adds the filters of a file to the ones read so far, replacing
+// those with the same name.
+func mergeFilters(filters, overrides []Filter) []Filter {
+	merged := append([]Filter{}, filters...)
+	for _, override := range overrides {
+		merged = replaceFilter(merged, override)
+	}
+	return merged
+}
+
+func replaceFilter(filters []Filter, filter Filter) []Filter {
+	for i := range filters {
+		if filters[i].Name == filter.Name {
+			filters[i] = filter
+			return filters
+		}
+	}
+	return append(filters, filter)
+}
+
+// SaveFilter stores filter in the user file, replacing the filter with the
+// same name. The rest of the file is kept as is.
+func SaveFilter(filter Filter) error {
+	if filter.Name == "" {
+		return fmt.Errorf("filters need a name")
+	}
+	path, ok := userFile()
+	if !ok {
+		return fmt.Errorf("cannot locate the user configuration file")
+	}
+
+	var document yaml.Node
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping at the top level", path)
+	}
+
+	var filters []Filter
+	var node *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "filters" {
+			node = root.Content[i+1]
+			if err := node.Decode(&filters); err != nil {
+				return fmt.Errorf("%s: invalid filters: %v", path, err)
+			}
+		}
+	}
+	if node == nil {
+		node = &yaml.Node{}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "filters"}, node)
+	}
+	if err := node.Encode(replaceFilter(filters, filter)); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&document)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
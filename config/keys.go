@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultKeybindings returns the keys of every binding of the UI, by name.
+// The keys of the list itself, such as h/l to change pages or g/G to jump to
+// its ends, are left free.
+func DefaultKeybindings() map[string][]string {
+	return map[string][]string{
+		"quit":            {"ctrl+c"},
+		"run":             {"enter"},
+		"cancel":          {"x"},
+		"select":          {" "},
+		"select_project":  {"p"},
+		"select_region":   {"r"},
+		"clear_selection": {"c"},
+		"graph":           {"ctrl+g"},
+		"plan":            {"v"},
+		"drift":           {"D"},
+		"history":         {"H"},
+		"effective":       {"e"},
+		"modules":         {"M"},
+		"inputs":          {"i"},
+		"compare":         {"="},
+		"search":          {"/"},
+		"find":            {"F"},
+		"save_filter":     {"s"},
+		"tree":            {"t"},
+		"expand":          {"o"},
+		"next":            {"n"},
+		"left":            {"left", "shift+tab"},
+		"right":           {"right", "tab"},
+		"up":              {"up", "k"},
+		"down":            {"down", "j"},
+		"back":            {"esc", "q"},
+		"confirm":         {"y", "Y"},
+	}
+}
+
+// validateKeybindings checks that every binding is known and has keys, and
+// that no key is bound twice.
+func validateKeybindings(bindings map[string][]string) error {
+	defaults := DefaultKeybindings()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bound := make(map[string]string)
+	for _, name := range names {
+		if _, ok := defaults[name]; !ok {
+			return fmt.Errorf("unknown key binding %q", name)
+		}
+		if len(bindings[name]) == 0 {
+			return fmt.Errorf("key binding %q has no keys", name)
+		}
+		for _, key := range bindings[name] {
+			if other, ok := bound[key]; ok {
+				return fmt.Errorf("key %q is bound to both %q and %q", key, other, name)
+			}
+			bound[key] = name
+		}
+	}
+	return nil
+}
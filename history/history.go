@@ -0,0 +1,193 @@
+// Package history keeps a record of every terragrunt command run against a
+// stack, so past runs can be browsed after the UI or the pipeline exits.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+// Record is a single run of a command against a stack.
+type Record struct {
+	// Stack is the absolute path of the terragrunt file.
+	Stack    string    `json:"stack"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	ExitCode int       `json:"exit_code"`
+	Status   string    `json:"status"`
+	User     string    `json:"user"`
+	GitSHA   string    `json:"git_sha,omitempty"`
+	Output   string    `json:"output"`
+	Error    string    `json:"error,omitempty"`
+	Class    string    `json:"error_class,omitempty"`
+}
+
+func (r Record) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// NewRecord describes the result of a job that ran.
+func NewRecord(result terragrunt.JobResult) Record {
+	stack, err := filepath.Abs(result.Job.Path)
+	if err != nil {
+		stack = result.Job.Path
+	}
+	record := Record{
+		Stack:    stack,
+		Command:  result.Job.Command.Title(),
+		Args:     append([]string{result.Job.Command.Name}, result.Job.Command.Args...),
+		Start:    result.Started,
+		End:      result.Finished,
+		Status:   result.Status.String(),
+		User:     currentUser(),
+		GitSHA:   gitSHA(filepath.Dir(stack)),
+		Output:   result.Output,
+		ExitCode: result.ExitCode,
+		Class:    string(result.Class),
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	return record
+}
+
+// Store keeps records as JSON files, one directory per stack.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns $XDG_STATE_HOME/terragrunt-vision, falling back to
+// ~/.local/state/terragrunt-vision.
+func DefaultDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "terragrunt-vision"), nil
+}
+
+// StateDir returns dir, or DefaultDir when dir is empty. A leading ~ is
+// expanded to the home directory.
+func StateDir(dir string) (string, error) {
+	switch {
+	case dir == "":
+		return DefaultDir()
+	case dir == "~" || strings.HasPrefix(dir, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, dir[1:]), nil
+	}
+	return dir, nil
+}
+
+// Open returns the store in the state directory dir, as given to StateDir.
+func Open(dir string) (*Store, error) {
+	dir, err := StateDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the history directory: %v", err)
+	}
+	return &Store{dir: filepath.Join(dir, "history")}, nil
+}
+
+// Save adds record to the history of its stack.
+func (s *Store) Save(record Record) error {
+	dir := s.stackDir(record.Stack)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	name := fmt.Sprintf("%s-%s.json", record.Start.UTC().Format("20060102T150405.000000000Z"), sanitize(record.Command))
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to save history: %v", err)
+	}
+	return nil
+}
+
+// List returns the records of the stack whose terragrunt file is at stack,
+// newest first. Unreadable records are skipped.
+func (s *Store) List(stack string) ([]Record, error) {
+	abs, err := filepath.Abs(stack)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.stackDir(abs))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.stackDir(abs), entry.Name()))
+		if err != nil {
+			continue
+		}
+		var record Record
+		if json.Unmarshal(data, &record) == nil {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Start.After(records[j].Start) })
+	return records, nil
+}
+
+// stackDir names the directory of a stack after a hash of its path, which
+// keeps stacks of different repositories apart.
+func (s *Store) stackDir(stack string) string {
+	sum := sha256.Sum256([]byte(stack))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8]))
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// gitSHA returns the commit checked out in dir, or an empty string outside of
+// a git repository.
+func gitSHA(dir string) string {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,79 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Stack: "/live/vpc/terragrunt.hcl", Command: "plan", Start: start, End: start.Add(time.Minute), Status: "succeeded"},
+		{Stack: "/live/vpc/terragrunt.hcl", Command: "plan (no lock)", Start: start.Add(time.Hour), Status: "failed", ExitCode: 1},
+		{Stack: "/live/vpc/terragrunt.hcl", Command: "apply", Start: start.Add(-time.Hour), Status: "cancelled"},
+		{Stack: "/live/db/terragrunt.hcl", Command: "init", Start: start, Status: "succeeded"},
+	}
+	for _, record := range records {
+		if err := store.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Records that cannot be read are skipped.
+	if err := os.WriteFile(filepath.Join(store.stackDir("/live/vpc/terragrunt.hcl"), "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		stack string
+		want  []Record
+	}{
+		{"newest first", "/live/vpc/terragrunt.hcl", []Record{records[1], records[0], records[2]}},
+		{"other stack", "/live/db/terragrunt.hcl", []Record{records[3]}},
+		{"no runs", "/live/dns/terragrunt.hcl", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := store.List(test.stack)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Setenv("XDG_STATE_HOME", "/state")
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"", "/state/terragrunt-vision"},
+		{"~", home},
+		{"~/history", filepath.Join(home, "history")},
+		{"/var/lib/runs", "/var/lib/runs"},
+	}
+	for _, test := range tests {
+		got, err := StateDir(test.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("StateDir(%q) = %q, want %q", test.dir, got, test.want)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
-	ui "github.com/caiovfernandes/terragrunt-runner/ui"
+	"os"
+
+	"github.com/caiovfernandes/terragrunt-runner/cli"
 )
 
 func main() {
-	ui.Start()
+	os.Exit(cli.Execute(os.Args[1:]))
 }
@@ -0,0 +1,221 @@
+package terragrunt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const DefaultParallelism = 4
+
+type Status int
+
+const (
+	Queued Status = iota + 1
+	Running
+	Succeeded
+	Failed
+	Cancelled
+)
+
+func (s Status) String() string {
+	switch s {
+	case Queued:
+		return "queued"
+	case Running:
+		return "running"
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Cancelled:
+		return "cancelled"
+	}
+	return ""
+}
+
+// Job is a single command to run against the terragrunt file at Path. When
+// Context is set it is used instead of the batch context, which lets callers
+// cancel individual jobs.
+type Job struct {
+	Path    string
+	Command Command
+	Context context.Context
+}
+
+type JobResult struct {
+	Job    Job
+	Status Status
+	Result
+	// Plan holds the changes planned by successful plan jobs.
+	Plan *PlanChanges
+	Err  error
+	// Started and Finished are zero for jobs that never started.
+	Started  time.Time
+	Finished time.Time
+}
+
+// notStarted is the result of jobs that never ran.
+var notStarted = Result{ExitCode: -1}
+
+// Batch runs a set of jobs through a pool of at most Parallelism workers. The
+// callbacks are optional and may be invoked concurrently from several workers.
+//
+// When Graph is set the jobs run in dependency order, level by level, and jobs
+// whose dependencies failed are not started. Destroy runs in reverse order.
+type Batch struct {
+	Jobs        []Job
+	Parallelism int
+	Graph       *Graph
+	OnStart     func(job Job)
+	OnOutput    func(job Job, line string)
+	OnFinish    func(result JobResult)
+}
+
+// Run executes every job and returns their results in the order of b.Jobs.
+// Jobs that are still queued when their context is cancelled are reported as
+// Cancelled without being started.
+func (b Batch) Run(ctx context.Context) []JobResult {
+	results := make([]JobResult, len(b.Jobs))
+	if b.Graph == nil {
+		indexes := make([]int, len(b.Jobs))
+		for i := range b.Jobs {
+			indexes[i] = i
+		}
+		b.runPool(ctx, indexes, results)
+		return results
+	}
+
+	byUnit := make(map[string][]int)
+	units := make([]string, 0, len(b.Jobs))
+	reverse := false
+	for i, job := range b.Jobs {
+		unit := filepath.Dir(job.Path)
+		if _, ok := byUnit[unit]; !ok {
+			units = append(units, unit)
+		}
+		byUnit[unit] = append(byUnit[unit], i)
+		reverse = reverse || job.Command.Name == Destroy.Name
+	}
+
+	levels, err := b.Graph.Order(units, reverse)
+	if err != nil {
+		for i, job := range b.Jobs {
+			results[i] = JobResult{Job: job, Status: Failed, Result: notStarted, Err: err}
+			if b.OnFinish != nil {
+				b.OnFinish(results[i])
+			}
+		}
+		return results
+	}
+
+	failed := make(map[string]bool)
+	for _, level := range levels {
+		var indexes []int
+		for _, unit := range level {
+			blocker := b.failedDependency(unit, reverse, failed)
+			for _, i := range byUnit[unit] {
+				if blocker == "" {
+					indexes = append(indexes, i)
+					continue
+				}
+				results[i] = JobResult{Job: b.Jobs[i], Status: Failed, Result: notStarted, Err: fmt.Errorf("dependency %s did not succeed", blocker)}
+				if b.OnFinish != nil {
+					b.OnFinish(results[i])
+				}
+			}
+		}
+		b.runPool(ctx, indexes, results)
+		for _, unit := range level {
+			for _, i := range byUnit[unit] {
+				if results[i].Status != Succeeded {
+					failed[unit] = true
+				}
+			}
+		}
+	}
+	return results
+}
+
+// failedDependency returns a unit that must run before unit and did not
+// succeed, or an empty string when unit is free to run.
+func (b Batch) failedDependency(unit string, reverse bool, failed map[string]bool) string {
+	blockers := b.Graph.Upstream(unit)
+	if reverse {
+		blockers = b.Graph.Downstream(unit)
+	}
+	for _, blocker := range blockers {
+		if failed[blocker] {
+			return blocker
+		}
+	}
+	return ""
+}
+
+// runPool runs the jobs at indexes concurrently and stores their results.
+func (b Batch) runPool(ctx context.Context, indexes []int, results []JobResult) {
+	parallelism := b.Parallelism
+	if parallelism < 1 {
+		parallelism = DefaultParallelism
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism && w < len(indexes); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = b.runJob(ctx, b.Jobs[i])
+				if b.OnFinish != nil {
+					b.OnFinish(results[i])
+				}
+			}
+		}()
+	}
+	for _, i := range indexes {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func (b Batch) runJob(ctx context.Context, job Job) JobResult {
+	if job.Context != nil {
+		ctx = job.Context
+	}
+	if ctx.Err() != nil {
+		return JobResult{Job: job, Status: Cancelled, Result: notStarted, Err: ctx.Err()}
+	}
+
+	if b.OnStart != nil {
+		b.OnStart(job)
+	}
+	onOutput := func(line string) {
+		if b.OnOutput != nil {
+			b.OnOutput(job, line)
+		}
+	}
+	var (
+		run  Result
+		plan *PlanChanges
+		err  error
+	)
+	started := time.Now()
+	if job.Command.Name == Plan.Name {
+		run, plan, err = RunPlan(ctx, job.Path, job.Command, onOutput)
+	} else {
+		run, err = RunCommand(ctx, job.Path, job.Command, onOutput)
+	}
+
+	result := JobResult{Job: job, Status: Succeeded, Result: run, Plan: plan, Err: err, Started: started, Finished: time.Now()}
+	if errors.Is(err, context.Canceled) {
+		result.Status = Cancelled
+	} else if err != nil {
+		result.Status = Failed
+	}
+	return result
+}
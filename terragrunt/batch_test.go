@@ -0,0 +1,231 @@
+package terragrunt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTerragrunt puts a terragrunt script on PATH that logs when it starts and
+// ends in each unit, sleeping in between. It fails in the unit named by
+// $TERRAGRUNT_FAIL and hangs in the unit named by $TERRAGRUNT_HANG, once it
+// printed a line, until it is interrupted. It returns the path of the log.
+func fakeTerragrunt(t *testing.T) string {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	script := `#!/bin/sh
+unit=$(basename "$(pwd)")
+echo "start $unit" >> "$TERRAGRUNT_LOG"
+if [ "$unit" = "$TERRAGRUNT_HANG" ]; then
+  echo "waiting"
+  exec sleep 10
+fi
+sleep 0.2
+echo "end $unit" >> "$TERRAGRUNT_LOG"
+if [ "$unit" = "$TERRAGRUNT_FAIL" ]; then
+  echo "Error acquiring the state lock" >&2
+  exit 1
+fi
+`
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Static credentials, so that none are looked up.
+	credentials := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentials, []byte("[default]\naws_access_key_id = test\naws_secret_access_key = test\nregion = us-east-2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TERRAGRUNT_LOG", log)
+	t.Setenv("AWS_CONFIG_FILE", credentials)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	return log
+}
+
+func readLog(t *testing.T, log string) []string {
+	data, err := os.ReadFile(log)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// testUnits creates the directories of units and returns their terragrunt
+// files, by unit.
+func testUnits(t *testing.T, units ...string) map[string]string {
+	root := t.TempDir()
+	paths := make(map[string]string)
+	for _, unit := range units {
+		dir := filepath.Join(root, unit)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		paths[unit] = filepath.Join(dir, "terragrunt.hcl")
+	}
+	return paths
+}
+
+func TestBatchParallelism(t *testing.T) {
+	for _, parallelism := range []int{1, 2, 3} {
+		t.Run(fmt.Sprint(parallelism), func(t *testing.T) {
+			log := fakeTerragrunt(t)
+			units := []string{"a", "b", "c", "d", "e", "f"}
+			paths := testUnits(t, units...)
+			var jobs []Job
+			for _, unit := range units {
+				jobs = append(jobs, Job{Path: paths[unit], Command: Init})
+			}
+			for _, result := range (Batch{Jobs: jobs, Parallelism: parallelism}).Run(context.Background()) {
+				if result.Status != Succeeded {
+					t.Fatalf("%s: %s: %v", result.Job.Path, result.Status, result.Err)
+				}
+			}
+
+			running, most := 0, 0
+			for _, event := range readLog(t, log) {
+				if strings.HasPrefix(event, "start ") {
+					running++
+					most = max(most, running)
+				} else {
+					running--
+				}
+			}
+			if most != parallelism {
+				t.Errorf("ran %d jobs at once, want %d", most, parallelism)
+			}
+		})
+	}
+}
+
+func TestBatchOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		command Command
+		fail    string
+		// before lists the units that must end before others start.
+		before [][2]string
+		status map[string]Status
+	}{
+		{
+			name:    "dependencies first",
+			command: Apply,
+			before:  [][2]string{{"vpc", "db"}, {"vpc", "app"}, {"db", "app"}},
+			status:  map[string]Status{"vpc": Succeeded, "db": Succeeded, "app": Succeeded, "dns": Succeeded},
+		},
+		{
+			name:    "destroy dependents first",
+			command: Destroy,
+			before:  [][2]string{{"app", "db"}, {"db", "vpc"}, {"app", "vpc"}},
+			status:  map[string]Status{"vpc": Succeeded, "db": Succeeded, "app": Succeeded, "dns": Succeeded},
+		},
+		{
+			name:    "failed dependency",
+			command: Apply,
+			fail:    "db",
+			status:  map[string]Status{"vpc": Succeeded, "db": Failed, "app": Failed, "dns": Succeeded},
+		},
+		{
+			name:    "failed dependent on destroy",
+			command: Destroy,
+			fail:    "db",
+			status:  map[string]Status{"vpc": Failed, "db": Failed, "app": Succeeded, "dns": Succeeded},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := fakeTerragrunt(t)
+			t.Setenv("TERRAGRUNT_FAIL", test.fail)
+			paths := testUnits(t, "app", "db", "vpc", "dns")
+			dir := func(unit string) string { return filepath.Dir(paths[unit]) }
+			workspace := testWorkspace(map[string][]string{
+				paths["app"]: {dir("db"), dir("vpc")},
+				paths["db"]:  {dir("vpc")},
+				paths["vpc"]: nil,
+				paths["dns"]: nil,
+			})
+
+			var jobs []Job
+			for _, unit := range []string{"app", "db", "dns", "vpc"} {
+				jobs = append(jobs, Job{Path: paths[unit], Command: test.command})
+			}
+			batch := Batch{Jobs: jobs, Parallelism: 4, Graph: workspace.Graph()}
+			for _, result := range batch.Run(context.Background()) {
+				unit := filepath.Base(filepath.Dir(result.Job.Path))
+				if result.Status != test.status[unit] {
+					t.Errorf("%s: %s, want %s (%v)", unit, result.Status, test.status[unit], result.Err)
+				}
+				if result.Err != nil && strings.Contains(result.Err.Error(), "did not succeed") && !result.Started.IsZero() {
+					t.Errorf("%s started after its dependency failed", unit)
+				}
+			}
+
+			events := readLog(t, log)
+			index := func(event string) int {
+				for i, e := range events {
+					if e == event {
+						return i
+					}
+				}
+				t.Fatalf("no %s in %v", event, events)
+				return -1
+			}
+			for _, pair := range test.before {
+				if index("end "+pair[0]) > index("start "+pair[1]) {
+					t.Errorf("%s started before %s ended: %v", pair[1], pair[0], events)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchCancelJob(t *testing.T) {
+	fakeTerragrunt(t)
+	t.Setenv("TERRAGRUNT_HANG", "slow")
+	paths := testUnits(t, "slow", "queued", "other")
+
+	slowCtx, cancelSlow := context.WithCancel(context.Background())
+	defer cancelSlow()
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	cancelQueued()
+	batch := Batch{
+		Jobs: []Job{
+			{Path: paths["slow"], Command: Init, Context: slowCtx},
+			{Path: paths["queued"], Command: Init, Context: queuedCtx},
+			{Path: paths["other"], Command: Init},
+		},
+		Parallelism: 3,
+		// Cancel the slow job once it runs.
+		OnOutput: func(job Job, line string) {
+			if job.Path == paths["slow"] {
+				cancelSlow()
+			}
+		},
+	}
+	results := batch.Run(context.Background())
+
+	tests := []struct {
+		name    string
+		status  Status
+		started bool
+	}{
+		{"slow", Cancelled, true},
+		{"queued", Cancelled, false},
+		{"other", Succeeded, true},
+	}
+	for i, test := range tests {
+		if results[i].Status != test.status {
+			t.Errorf("%s: %s, want %s (%v)", test.name, results[i].Status, test.status, results[i].Err)
+		}
+		if started := !results[i].Started.IsZero(); started != test.started {
+			t.Errorf("%s: started = %v, want %v", test.name, started, test.started)
+		}
+	}
+}
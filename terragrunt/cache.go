@@ -0,0 +1,135 @@
+package terragrunt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion changes whenever the cached data changes shape, which drops
+// the caches written before.
+const cacheVersion = 2
+
+// scanCache keeps the parsed configuration of every file of a workspace
+// between runs, so that only the files modified since are parsed again.
+type scanCache struct {
+	path    string
+	entries map[string]cacheEntry
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// cacheEntry is the result of parsing a file. It is valid as long as the
+// file and the files it read keep their modification time and size, the
+// files it looked for are still missing, e.g. a parent terragrunt.hcl, and
+// the environment variables it read keep their value.
+type cacheEntry struct {
+	Files        []cachedFile     `json:"files"`
+	Missing      []string         `json:"missing,omitempty"`
+	Env          []cachedEnv      `json:"env,omitempty"`
+	Config       *Config          `json:"config,omitempty"`
+	Effective    *EffectiveConfig `json:"effective,omitempty"`
+	Dependencies []string         `json:"dependencies,omitempty"`
+	Diagnostics  []Diagnostic     `json:"diagnostics,omitempty"`
+}
+
+type cachedFile struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+type cachedEnv struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Set   bool   `json:"set"`
+}
+
+// openCache reads the cache of the workspace at root from dir. A missing or
+// unreadable cache is empty.
+func openCache(dir, root string) *scanCache {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	sum := sha256.Sum256([]byte(abs))
+	cache := &scanCache{
+		path:    filepath.Join(dir, hex.EncodeToString(sum[:])+".json"),
+		entries: make(map[string]cacheEntry),
+	}
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+	var file cacheFile
+	if json.Unmarshal(data, &file) == nil && file.Version == cacheVersion && file.Entries != nil {
+		cache.entries = file.Entries
+	}
+	return cache
+}
+
+// lookup returns the entry of the file at path if nothing it depends on
+// changed.
+func (c *scanCache) lookup(path string) (cacheEntry, bool) {
+	entry, ok := c.entries[path]
+	if !ok || len(entry.Files) == 0 {
+		return cacheEntry{}, false
+	}
+	for _, file := range entry.Files {
+		if current, err := statFile(file.Path); err != nil || !current.ModTime.Equal(file.ModTime) || current.Size != file.Size {
+			return cacheEntry{}, false
+		}
+	}
+	for _, path := range entry.Missing {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			return cacheEntry{}, false
+		}
+	}
+	for _, env := range entry.Env {
+		if value, set := os.LookupEnv(env.Name); set != env.Set || value != env.Value {
+			return cacheEntry{}, false
+		}
+	}
+	return entry, true
+}
+
+// save replaces the cache with entries.
+func (c *scanCache) save(entries map[string]cacheEntry) error {
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Entries: entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	// Write and rename so that concurrent runs never read half a cache.
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func statFile(path string) (cachedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cachedFile{}, err
+	}
+	return cachedFile{Path: path, ModTime: info.ModTime(), Size: info.Size()}, nil
+}
@@ -0,0 +1,192 @@
+package terragrunt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caiovfernandes/terragrunt-runner/utils"
+)
+
+type Command struct {
+	// Label names the command in the UI when it differs from Name, e.g. for
+	// command profiles.
+	Label       string
+	Name        string
+	Args        []string
+	Env         []string
+	Destructive bool
+	AWSProfile  string
+	AWSRegion   string
+}
+
+const cancelGracePeriod = 10 * time.Second
+
+var (
+	Init        = Command{Name: "init"}
+	Plan        = Command{Name: "plan"}
+	Validate    = Command{Name: "validate"}
+	Output      = Command{Name: "output"}
+	Apply       = Command{Name: "apply", Args: []string{"-auto-approve"}, Destructive: true}
+	Destroy     = Command{Name: "destroy", Args: []string{"-auto-approve"}, Destructive: true}
+	Commands    = []Command{Init, Plan, Validate, Output, Apply, Destroy}
+	defaultArgs = []string{"--terragrunt-non-interactive", "--terragrunt-forward-tf-stdout", "--no-color"}
+)
+
+func (c Command) String() string {
+	return strings.Join(append([]string{"terragrunt", c.Name}, c.Args...), " ")
+}
+
+func (c Command) Title() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return c.Name
+}
+
+func (c Command) args() []string {
+	args := append([]string{c.Name}, c.Args...)
+	return append(args, defaultArgs...)
+}
+
+// OutputFunc receives each line written to stdout or stderr by a running
+// command. Lines are passed whole and one at a time, those of each stream in
+// the order they were written.
+type OutputFunc func(line string)
+
+// RunCommand executes the given terragrunt command in the directory of the
+// terragrunt file at stackPath. Output lines are streamed to onOutput while the
+// command runs, and the result is returned once it exits. The result is filled
+// in as far as possible when an error is returned too.
+//
+// Cancelling ctx sends SIGINT to terragrunt so it can release state locks, and
+// kills it if it is still running after cancelGracePeriod. The returned error
+// then wraps context.Canceled.
+func RunCommand(ctx context.Context, stackPath string, command Command, onOutput OutputFunc) (Result, error) {
+	result := Result{ExitCode: -1}
+	cmd, err := newCmd(ctx, stackPath, command)
+	if err != nil {
+		return result, err
+	}
+
+	// stdout and stderr are scanned on their own so that a line of one is
+	// never split by the other, and their lines are passed on under a lock as
+	// they come. stderr is also kept on its own to explain failures.
+	var (
+		mu             sync.Mutex
+		wg             sync.WaitGroup
+		output, stderr strings.Builder
+	)
+	scan := func(reader io.Reader, kept *strings.Builder) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			mu.Lock()
+			output.WriteString(line + "\n")
+			if kept != nil {
+				kept.WriteString(line + "\n")
+			}
+			if onOutput != nil {
+				onOutput(line)
+			}
+			mu.Unlock()
+		}
+		// Keep draining so the child never blocks on a full pipe.
+		io.Copy(ioutil.Discard, reader)
+	}
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	cmd.Stdout, cmd.Stderr = stdoutWriter, stderrWriter
+	wg.Add(2)
+	go scan(stdoutReader, nil)
+	go scan(stderrReader, &stderr)
+
+	started := time.Now()
+	runErr := cmd.Run()
+	result.Duration = time.Since(started)
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	wg.Wait()
+
+	result.Output = output.String()
+	result.StderrTail = tail(stderr.String(), stderrTailLines)
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() != nil {
+		return result, fmt.Errorf("%s: %w", command, ctx.Err())
+	}
+	if runErr != nil {
+		// Plans exit with 2 under -detailed-exitcode when they find changes,
+		// which is not a failure to classify.
+		if !hasChangesExit(command, runErr) {
+			result.Class = classifyError(stderr.String())
+		}
+		if result.Class != "" {
+			return result, fmt.Errorf("%s failed (%s): %w", command, result.Class, runErr)
+		}
+		return result, fmt.Errorf("%s failed: %w", command, runErr)
+	}
+	return result, nil
+}
+
+// captureCommand runs command like RunCommand but returns only what it wrote
+// to stdout, for commands whose output is parsed.
+func captureCommand(ctx context.Context, stackPath string, command Command) ([]byte, error) {
+	cmd, err := newCmd(ctx, stackPath, command)
+	if err != nil {
+		return nil, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return output, fmt.Errorf("%s: %w", command, ctx.Err())
+	}
+	if err != nil {
+		return output, fmt.Errorf("%s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func newCmd(ctx context.Context, stackPath string, command Command) (*exec.Cmd, error) {
+	accessKeyID, secretAccessKey, sessionToken, err := utils.GetAwsCredentials(command.AWSProfile, command.AWSRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "terragrunt", command.args()...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = cancelGracePeriod
+	cmd.Env = append(os.Environ(),
+		"AWS_ACCESS_KEY_ID="+accessKeyID,
+		"AWS_SECRET_ACCESS_KEY="+secretAccessKey,
+		"AWS_SESSION_TOKEN="+sessionToken,
+	)
+	cmd.Env = append(cmd.Env, command.Env...)
+	cmd.Dir = filepath.Dir(stackPath)
+	return cmd, nil
+}
+
+// ExitCode returns the exit code of the terragrunt process behind err, or -1
+// when err is not caused by the process exiting.
+func ExitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
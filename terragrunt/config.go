@@ -0,0 +1,329 @@
+package terragrunt
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Config is the parsed content of a terragrunt file.
+//
+// Values are plain Go values as produced by encoding/json: nil, bool,
+// float64, string, []interface{} and map[string]interface{}. Expressions
+// that cannot be evaluated without running terragrunt, such as dependency
+// outputs, are kept as interpolation strings like
+// "${dependency.vpc.outputs.vpc_id}".
+type Config struct {
+	Terraform    *Terraform             `json:"terraform,omitempty"`
+	Includes     []Include              `json:"includes,omitempty"`
+	Locals       map[string]interface{} `json:"locals,omitempty"`
+	Inputs       map[string]interface{} `json:"inputs,omitempty"`
+	RemoteState  *RemoteState           `json:"remote_state,omitempty"`
+	Dependencies []Dependency           `json:"dependencies,omitempty"`
+	// DependencyPaths are the paths of the `dependencies` block.
+	DependencyPaths []string   `json:"dependency_paths,omitempty"`
+	Generates       []Generate `json:"generates,omitempty"`
+}
+
+type Terraform struct {
+	Source string `json:"source"`
+}
+
+type Include struct {
+	// Name is the label of the block, empty for a bare `include` block.
+	Name          string `json:"name,omitempty"`
+	Path          string `json:"path"`
+	Expose        bool   `json:"expose,omitempty"`
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+}
+
+type RemoteState struct {
+	Backend  string                 `json:"backend"`
+	Config   map[string]interface{} `json:"config,omitempty"`
+	Generate map[string]interface{} `json:"generate,omitempty"`
+}
+
+type Dependency struct {
+	Name        string                 `json:"name"`
+	ConfigPath  string                 `json:"config_path"`
+	SkipOutputs bool                   `json:"skip_outputs,omitempty"`
+	MockOutputs map[string]interface{} `json:"mock_outputs,omitempty"`
+}
+
+type Generate struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	IfExists string `json:"if_exists,omitempty"`
+	Contents string `json:"contents"`
+}
+
+// IsExpression reports whether a string value holds an expression that was
+// not evaluated.
+func IsExpression(value string) bool {
+	return strings.Contains(value, "${")
+}
+
+// ParseConfig parses the terragrunt file at filePath. Only syntax errors are
+// reported: attributes that cannot be evaluated are kept as expressions.
+func ParseConfig(filePath string, content []byte) (*Config, error) {
+	return parseConfig(filePath, content, scope{dir: absDir(filePath)})
+}
+
+// absDir returns the absolute directory of filePath, which is what functions
+// such as get_terragrunt_dir() return.
+func absDir(filePath string) string {
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return filepath.Dir(filePath)
+	}
+	return dir
+}
+
+func parseConfig(filePath string, content []byte, s scope) (*Config, error) {
+	file, diags := hclsyntax.ParseConfig(content, filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body := file.Body.(*hclsyntax.Body)
+	p := parser{source: content, scope: s}
+	p.evalLocals(body)
+
+	config := &Config{Locals: p.localValues()}
+	if attr, ok := body.Attributes["inputs"]; ok {
+		config.Inputs = mapOf(p.value(attr.Expr))
+	}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "terraform":
+			config.Terraform = &Terraform{Source: p.string(block.Body, "source")}
+		case "include":
+			config.Includes = append(config.Includes, Include{
+				Name:          label(block),
+				Path:          p.string(block.Body, "path"),
+				Expose:        p.bool(block.Body, "expose"),
+				MergeStrategy: p.string(block.Body, "merge_strategy"),
+			})
+		case "remote_state":
+			config.RemoteState = &RemoteState{
+				Backend:  p.string(block.Body, "backend"),
+				Config:   p.object(block.Body, "config"),
+				Generate: p.object(block.Body, "generate"),
+			}
+		case "dependency":
+			config.Dependencies = append(config.Dependencies, Dependency{
+				Name:        label(block),
+				ConfigPath:  p.string(block.Body, "config_path"),
+				SkipOutputs: p.bool(block.Body, "skip_outputs"),
+				MockOutputs: p.object(block.Body, "mock_outputs"),
+			})
+		case "dependencies":
+			if attr, ok := block.Body.Attributes["paths"]; ok {
+				if paths, ok := p.value(attr.Expr).([]interface{}); ok {
+					for _, path := range paths {
+						config.DependencyPaths = append(config.DependencyPaths, fmt.Sprint(path))
+					}
+				}
+			}
+		case "generate":
+			config.Generates = append(config.Generates, Generate{
+				Name:     label(block),
+				Path:     p.string(block.Body, "path"),
+				IfExists: p.string(block.Body, "if_exists"),
+				Contents: p.string(block.Body, "contents"),
+			})
+		}
+	}
+	return config, nil
+}
+
+// DependencyDirs returns the directories referenced by the `dependency` and
+// `dependencies` blocks. Relative paths are resolved against dir, the
+// directory of the file.
+func (c *Config) DependencyDirs(dir string) ([]string, error) {
+	var paths []string
+	for _, dependency := range c.Dependencies {
+		if dependency.ConfigPath == "" || IsExpression(dependency.ConfigPath) {
+			return nil, fmt.Errorf("dependency %q: config_path must be a string", dependency.Name)
+		}
+		paths = append(paths, dependency.ConfigPath)
+	}
+	for _, path := range c.DependencyPaths {
+		if IsExpression(path) {
+			return nil, fmt.Errorf("dependencies: paths must be a list of strings")
+		}
+		paths = append(paths, path)
+	}
+
+	// Absolute paths, e.g. built with get_terragrunt_dir(), are made relative
+	// like dir so that units compare equal.
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		switch {
+		case !filepath.IsAbs(path):
+			path = filepath.Join(dir, path)
+		case !filepath.IsAbs(dir):
+			if rel, err := filepath.Rel(absDir, path); err == nil {
+				path = filepath.Join(dir, rel)
+			}
+		}
+		dirs = append(dirs, filepath.Clean(path))
+	}
+	return dirs, nil
+}
+
+// parser evaluates the expressions of a single file.
+type parser struct {
+	source []byte
+	scope  scope
+	locals map[string]cty.Value
+	// pending are the locals that could not be evaluated.
+	pending map[string]hclsyntax.Expression
+}
+
+// evalLocals evaluates the locals that only depend on each other, in as many
+// passes as needed.
+func (p *parser) evalLocals(body *hclsyntax.Body) {
+	p.locals = make(map[string]cty.Value)
+	p.pending = make(map[string]hclsyntax.Expression)
+	for _, block := range body.Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		for name, attr := range block.Body.Attributes {
+			p.pending[name] = attr.Expr
+		}
+	}
+	for progress := true; progress; {
+		progress = false
+		ctx := p.context()
+		for name, expr := range p.pending {
+			value, diags := expr.Value(ctx)
+			if diags.HasErrors() || !value.IsWhollyKnown() {
+				continue
+			}
+			p.locals[name] = value
+			delete(p.pending, name)
+			progress = true
+		}
+	}
+}
+
+func (p *parser) context() *hcl.EvalContext {
+	return evalContext(p.scope, p.locals)
+}
+
+func (p *parser) localValues() map[string]interface{} {
+	if len(p.locals)+len(p.pending) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{})
+	for name, value := range p.locals {
+		values[name] = goValue(value)
+	}
+	for name, expr := range p.pending {
+		values[name] = p.value(expr)
+	}
+	return values
+}
+
+// value evaluates expr, descending into object and tuple constructors so that
+// only the parts that cannot be evaluated are kept as expressions.
+func (p *parser) value(expr hclsyntax.Expression) interface{} {
+	ctx := p.context()
+	if value, diags := expr.Value(ctx); !diags.HasErrors() && value.IsWhollyKnown() {
+		return goValue(value)
+	}
+
+	switch expr := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		object := make(map[string]interface{}, len(expr.Items))
+		for _, item := range expr.Items {
+			key, diags := item.KeyExpr.Value(ctx)
+			name := p.text(item.KeyExpr)
+			if !diags.HasErrors() && key.Type() == cty.String && key.IsKnown() {
+				name = key.AsString()
+			}
+			object[name] = p.value(item.ValueExpr)
+		}
+		return object
+	case *hclsyntax.TupleConsExpr:
+		tuple := make([]interface{}, len(expr.Exprs))
+		for i, element := range expr.Exprs {
+			tuple[i] = p.value(element)
+		}
+		return tuple
+	case *hclsyntax.TemplateExpr:
+		if text := p.text(expr); strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) && len(text) > 1 {
+			return text[1 : len(text)-1]
+		}
+	}
+	return "${" + p.text(expr) + "}"
+}
+
+// text returns the source of expr.
+func (p *parser) text(expr hclsyntax.Expression) string {
+	return strings.TrimSpace(string(expr.Range().SliceBytes(p.source)))
+}
+
+func (p *parser) string(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	if s, ok := p.value(attr.Expr).(string); ok {
+		return s
+	}
+	return "${" + p.text(attr.Expr) + "}"
+}
+
+func (p *parser) bool(body *hclsyntax.Body, name string) bool {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return false
+	}
+	b, _ := p.value(attr.Expr).(bool)
+	return b
+}
+
+func (p *parser) object(body *hclsyntax.Body, name string) map[string]interface{} {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return nil
+	}
+	return mapOf(p.value(attr.Expr))
+}
+
+func mapOf(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func label(block *hclsyntax.Block) string {
+	if len(block.Labels) > 0 {
+		return block.Labels[0]
+	}
+	return ""
+}
+
+// goValue converts a wholly known value to its encoding/json representation.
+func goValue(value cty.Value) interface{} {
+	data, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		locals  map[string]interface{}
+		inputs  map[string]interface{}
+	}{
+		{
+			name: "locals across passes",
+			content: `
+locals {
+  c = "${local.b}-c"
+  b = "${local.a}-b"
+  a = "x"
+}
+inputs = { name = local.c }
+`,
+			locals: map[string]interface{}{"a": "x", "b": "x-b", "c": "x-b-c"},
+			inputs: map[string]interface{}{"name": "x-b-c"},
+		},
+		{
+			name: "dependency outputs stay expressions",
+			content: `
+locals {
+  vpc = dependency.vpc.outputs.vpc_id
+}
+inputs = {
+  vpc_id = dependency.vpc.outputs.vpc_id
+  name   = "app-${dependency.vpc.outputs.name}"
+  count  = 2
+}
+`,
+			locals: map[string]interface{}{"vpc": "${dependency.vpc.outputs.vpc_id}"},
+			inputs: map[string]interface{}{
+				"vpc_id": "${dependency.vpc.outputs.vpc_id}",
+				"name":   "app-${dependency.vpc.outputs.name}",
+				"count":  float64(2),
+			},
+		},
+		{
+			name: "only the unknown parts of objects and lists",
+			content: `
+locals {
+  team = "core"
+}
+inputs = {
+  tags    = { Team = local.team, Subnet = dependency.vpc.outputs.subnet }
+  subnets = ["a", dependency.vpc.outputs.b]
+}
+`,
+			locals: map[string]interface{}{"team": "core"},
+			inputs: map[string]interface{}{
+				"tags":    map[string]interface{}{"Team": "core", "Subnet": "${dependency.vpc.outputs.subnet}"},
+				"subnets": []interface{}{"a", "${dependency.vpc.outputs.b}"},
+			},
+		},
+		{
+			name: "locals depending on unknown locals",
+			content: `
+locals {
+  id   = dependency.db.outputs.id
+  name = "db-${local.id}"
+}
+`,
+			locals: map[string]interface{}{"id": "${dependency.db.outputs.id}", "name": "db-${local.id}"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := ParseConfig("live/app/terragrunt.hcl", []byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config.Locals, test.locals) {
+				t.Errorf("locals = %#v, want %#v", config.Locals, test.locals)
+			}
+			if !reflect.DeepEqual(config.Inputs, test.inputs) {
+				t.Errorf("inputs = %#v, want %#v", config.Inputs, test.inputs)
+			}
+		})
+	}
+}
+
+func TestParseConfigBlocks(t *testing.T) {
+	config, err := ParseConfig("live/app/terragrunt.hcl", []byte(`
+terraform {
+  source = "git::https://example.com/mods.git//app?ref=v1.2.0"
+}
+include "root" {
+  path   = "../root.hcl"
+  expose = true
+}
+dependency "vpc" {
+  config_path = "../vpc"
+}
+dependencies {
+  paths = ["../db"]
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Terraform == nil || config.Terraform.Source != "git::https://example.com/mods.git//app?ref=v1.2.0" {
+		t.Errorf("terraform = %+v", config.Terraform)
+	}
+	if want := []Include{{Name: "root", Path: "../root.hcl", Expose: true}}; !reflect.DeepEqual(config.Includes, want) {
+		t.Errorf("includes = %+v, want %+v", config.Includes, want)
+	}
+	dirs, err := config.DependencyDirs("live/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"live/vpc", "live/db"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dependency dirs = %v, want %v", dirs, want)
+	}
+}
+
+func TestParseConfigSyntaxError(t *testing.T) {
+	if _, err := ParseConfig("terragrunt.hcl", []byte("inputs = {")); err == nil {
+		t.Error("got no error")
+	}
+}
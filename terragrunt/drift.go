@@ -0,0 +1,25 @@
+package terragrunt
+
+// Drift is the plan used to detect drift: it exits with 2 when the real
+// infrastructure differs from the configuration.
+var Drift = Command{Label: "drift", Name: "plan", Args: []string{"-detailed-exitcode"}}
+
+type DriftStatus string
+
+const (
+	InSync  DriftStatus = "in-sync"
+	Drifted DriftStatus = "drifted"
+	Errored DriftStatus = "errored"
+)
+
+// ClassifyDrift tells whether a stack is in sync from the outcome of its drift
+// plan.
+func ClassifyDrift(plan *PlanChanges, err error) DriftStatus {
+	switch {
+	case err != nil || plan == nil:
+		return Errored
+	case plan.HasChanges():
+		return Drifted
+	}
+	return InSync
+}
@@ -0,0 +1,86 @@
+package terragrunt
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects files of a workspace. It is parsed from an expression of
+// whitespace or comma separated terms, all of which must match:
+//
+//	project=prod region=us-* stack=vpc
+//
+// Keys are project, region, stack, path or the name of a layout segment, and
+// values are globs. Other keys are rejected. Repeating a key matches any of its values. A term without
+// a key is a glob matched against <project>/<region>/<stack>.
+type Filter struct {
+	terms map[string][]string
+}
+
+// filterKeys are the keys of every file, next to the segments of the layout.
+var filterKeys = []string{"project", "region", "stack", "path"}
+
+// ParseFilter parses a filter expression for the files placed by layout. An
+// empty expression matches every file.
+func ParseFilter(expression string, layout Layout) (Filter, error) {
+	keys := append(append([]string{}, filterKeys...), layout.segmentNames()...)
+	filter := Filter{terms: make(map[string][]string)}
+	fields := strings.FieldsFunc(expression, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			key, value = "", field
+		}
+		if key == "" && ok || value == "" {
+			return Filter{}, fmt.Errorf("invalid filter term %q, expected key=glob", field)
+		}
+		if key != "" && !contains(keys, key) {
+			return Filter{}, fmt.Errorf("unknown key in filter term %q, expected one of %s", field, strings.Join(keys, ", "))
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid glob in filter term %q: %v", field, err)
+		}
+		filter.terms[key] = append(filter.terms[key], value)
+	}
+	return filter, nil
+}
+
+// Match reports whether file matches every term of the filter.
+func (f Filter) Match(file File) bool {
+	for key, globs := range f.terms {
+		value, ok := filterValue(file, key)
+		if !ok || !matchAny(globs, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterValue(file File, key string) (string, bool) {
+	switch key {
+	case "":
+		return file.ProjectID + "/" + file.RegionID + "/" + file.StackID, true
+	case "project":
+		return file.ProjectID, true
+	case "region":
+		return file.RegionID, true
+	case "stack":
+		return file.StackID, true
+	case "path":
+		return file.Path, true
+	}
+	value, ok := file.Segments[key]
+	return value, ok
+}
+
+func matchAny(globs []string, value string) bool {
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package terragrunt
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	layout := Layout{Path: "live/{account}/{region}/{stack}", Project: "{account}"}
+	file := File{
+		Path:      "live/prod/us-east-1/vpc/terragrunt.hcl",
+		ProjectID: "prod",
+		RegionID:  "us-east-1",
+		StackID:   "vpc",
+		Segments:  map[string]string{"account": "prod", "region": "us-east-1", "stack": "vpc"},
+	}
+	tests := []struct {
+		expression string
+		match      bool
+	}{
+		{"", true},
+		{"project=prod", true},
+		{"project=staging", false},
+		{"region=us-*", true},
+		{"region=eu-*", false},
+		{"project=prod region=us-* stack=vpc", true},
+		{"project=prod,stack=db", false},
+		{"stack=db stack=vpc", true},
+		{"account=prod", true},
+		{"path=live/*/*/vpc/terragrunt.hcl", true},
+		{"prod/us-east-?/vpc", true},
+		{"prod/*", false},
+		{"*/*/vpc", true},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expression, layout)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", test.expression, err)
+			continue
+		}
+		if got := filter.Match(file); got != test.match {
+			t.Errorf("ParseFilter(%q).Match = %v, want %v", test.expression, got, test.match)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expression := range []string{
+		"regoin=eu-*",
+		"=prod",
+		"project=",
+		"project=[",
+		"env=prod",
+	} {
+		if _, err := ParseFilter(expression, DefaultLayout); err == nil {
+			t.Errorf("ParseFilter(%q): got no error", expression)
+		}
+	}
+}
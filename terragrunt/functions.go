@@ -0,0 +1,244 @@
+package terragrunt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// scope is what the functions of a terragrunt file are evaluated against.
+// Included files are evaluated in the scope of the file including them, like
+// terragrunt does.
+type scope struct {
+	// dir is the directory of the terragrunt file being run.
+	dir string
+	// includeDir is the directory of the included file being evaluated, or
+	// empty when evaluating the file of dir itself.
+	includeDir string
+	// include holds the exposed included configurations, by include name.
+	include map[string]cty.Value
+	// reads records what the evaluation depends on, when set.
+	reads *reads
+}
+
+// reads records what the evaluation of a file depends on outside of the file
+// itself, so that a cached result can be checked for changes.
+type reads struct {
+	// files were read or found.
+	files map[string]bool
+	// missing were looked for and did not exist.
+	missing map[string]bool
+	// env holds the environment variables read, nil when unset.
+	env map[string]*string
+}
+
+func newReads() *reads {
+	return &reads{files: make(map[string]bool), missing: make(map[string]bool), env: make(map[string]*string)}
+}
+
+func (r *reads) file(path string) {
+	if r != nil {
+		r.files[path] = true
+	}
+}
+
+func (r *reads) miss(path string) {
+	if r != nil {
+		r.missing[path] = true
+	}
+}
+
+func (r *reads) getenv(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	if r != nil {
+		r.env[name] = nil
+		if ok {
+			r.env[name] = &value
+		}
+	}
+	return value, ok
+}
+
+// sortedPaths returns the paths of set in order.
+func sortedPaths(set map[string]bool) []string {
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// evalContext exposes the functions that can be evaluated without running
+// terragrunt: the ones that only depend on the file system and environment,
+// and the pure functions of the Terraform language. Anything else, such as
+// dependency outputs, is left unevaluated.
+func evalContext(s scope, locals map[string]cty.Value) *hcl.EvalContext {
+	parentDir := s.includeDir
+	if parentDir == "" {
+		parentDir = s.dir
+	}
+	functions := map[string]function.Function{
+		"get_terragrunt_dir":          stringFunc(func() string { return s.dir }),
+		"get_original_terragrunt_dir": stringFunc(func() string { return s.dir }),
+		"get_parent_terragrunt_dir":   stringFunc(func() string { return parentDir }),
+		"path_relative_to_include":    stringFunc(func() string { return relativePath(parentDir, s.dir) }),
+		"path_relative_from_include":  stringFunc(func() string { return relativePath(s.dir, parentDir) }),
+		"find_in_parent_folders": function.New(&function.Spec{
+			VarParam: &function.Parameter{Name: "args", Type: cty.String},
+			Type:     function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				name, fallback := "terragrunt.hcl", ""
+				if len(args) > 0 {
+					name = args[0].AsString()
+				}
+				if len(args) > 1 {
+					fallback = args[1].AsString()
+				}
+				if path, ok := findInParentFolders(s.dir, name, s.reads); ok {
+					return cty.StringVal(path), nil
+				}
+				if len(args) > 1 {
+					return cty.StringVal(fallback), nil
+				}
+				return cty.NilVal, fmt.Errorf("could not find %s in the parent folders of %s", name, s.dir)
+			},
+		}),
+		"dirname":  pathFunc(filepath.Dir),
+		"basename": pathFunc(filepath.Base),
+		"abspath": pathFunc(func(path string) string {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return path
+			}
+			return abs
+		}),
+		"get_env": function.New(&function.Spec{
+			Params:   []function.Parameter{{Name: "name", Type: cty.String}},
+			VarParam: &function.Parameter{Name: "default", Type: cty.String},
+			Type:     function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				if value, ok := s.reads.getenv(args[0].AsString()); ok {
+					return cty.StringVal(value), nil
+				}
+				if len(args) > 1 {
+					return args[1], nil
+				}
+				return cty.StringVal(""), nil
+			},
+		}),
+	}
+	for name, fn := range stdlibFunctions {
+		functions[name] = fn
+	}
+
+	variables := map[string]cty.Value{"local": cty.ObjectVal(locals)}
+	if len(s.include) > 0 {
+		variables["include"] = cty.ObjectVal(s.include)
+	}
+	return &hcl.EvalContext{Variables: variables, Functions: functions}
+}
+
+// findInParentFolders looks for name in the parent directories of dir and
+// returns its absolute path. The paths looked at are recorded in r.
+func findInParentFolders(dir, name string, r *reads) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			r.file(path)
+			return path, true
+		}
+		r.miss(path)
+	}
+}
+
+func relativePath(base, target string) string {
+	absBase, err1 := filepath.Abs(base)
+	absTarget, err2 := filepath.Abs(target)
+	if err1 != nil || err2 != nil {
+		return target
+	}
+	rel, err := filepath.Rel(absBase, absTarget)
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
+
+func stringFunc(fn func() string) function.Function {
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(fn()), nil
+		},
+	})
+}
+
+func pathFunc(fn func(path string) string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "path", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(fn(args[0].AsString())), nil
+		},
+	})
+}
+
+var stdlibFunctions = map[string]function.Function{
+	"abs":        stdlib.AbsoluteFunc,
+	"ceil":       stdlib.CeilFunc,
+	"chomp":      stdlib.ChompFunc,
+	"coalesce":   stdlib.CoalesceFunc,
+	"compact":    stdlib.CompactFunc,
+	"concat":     stdlib.ConcatFunc,
+	"contains":   stdlib.ContainsFunc,
+	"distinct":   stdlib.DistinctFunc,
+	"element":    stdlib.ElementFunc,
+	"flatten":    stdlib.FlattenFunc,
+	"floor":      stdlib.FloorFunc,
+	"format":     stdlib.FormatFunc,
+	"formatlist": stdlib.FormatListFunc,
+	"indent":     stdlib.IndentFunc,
+	"join":       stdlib.JoinFunc,
+	"jsondecode": stdlib.JSONDecodeFunc,
+	"jsonencode": stdlib.JSONEncodeFunc,
+	"keys":       stdlib.KeysFunc,
+	"length":     stdlib.LengthFunc,
+	"lookup":     stdlib.LookupFunc,
+	"lower":      stdlib.LowerFunc,
+	"max":        stdlib.MaxFunc,
+	"merge":      stdlib.MergeFunc,
+	"min":        stdlib.MinFunc,
+	"range":      stdlib.RangeFunc,
+	"regex":      stdlib.RegexFunc,
+	"replace":    stdlib.ReplaceFunc,
+	"reverse":    stdlib.ReverseListFunc,
+	"slice":      stdlib.SliceFunc,
+	"sort":       stdlib.SortFunc,
+	"split":      stdlib.SplitFunc,
+	"strrev":     stdlib.ReverseFunc,
+	"substr":     stdlib.SubstrFunc,
+	"title":      stdlib.TitleFunc,
+	"trim":       stdlib.TrimFunc,
+	"trimprefix": stdlib.TrimPrefixFunc,
+	"trimspace":  stdlib.TrimSpaceFunc,
+	"trimsuffix": stdlib.TrimSuffixFunc,
+	"upper":      stdlib.UpperFunc,
+	"values":     stdlib.ValuesFunc,
+	"zipmap":     stdlib.ZipmapFunc,
+}
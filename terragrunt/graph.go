@@ -0,0 +1,259 @@
+package terragrunt
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Graph is the dependency graph between the terragrunt units of a workspace.
+// Units are identified by the directory that holds their terragrunt file.
+type Graph struct {
+	// Dependencies maps a unit to the units it depends on.
+	Dependencies map[string][]string
+	// Dependents maps a unit to the units that depend on it.
+	Dependents map[string][]string
+}
+
+// CycleError is returned when the units to order depend on each other.
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle detected: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// Graph builds the dependency graph of every file in the workspace.
+func (h *Workspace) Graph() *Graph {
+	g := &Graph{
+		Dependencies: make(map[string][]string),
+		Dependents:   make(map[string][]string),
+	}
+	for _, project := range h.Projects {
+		for _, region := range project.Regions {
+			for _, stack := range region.Stacks {
+				for _, file := range stack.Files {
+					unit := filepath.Dir(file.Path)
+					if _, ok := g.Dependencies[unit]; !ok {
+						g.Dependencies[unit] = nil
+					}
+					for _, dependency := range file.Dependencies {
+						g.Dependencies[unit] = append(g.Dependencies[unit], dependency)
+						g.Dependents[dependency] = append(g.Dependents[dependency], unit)
+					}
+				}
+			}
+		}
+	}
+	// A unit may name the same dependency in a `dependency` and a
+	// `dependencies` block.
+	for _, edges := range []map[string][]string{g.Dependencies, g.Dependents} {
+		for unit := range edges {
+			edges[unit] = uniqueSorted(edges[unit])
+		}
+	}
+	return g
+}
+
+// uniqueSorted sorts units in place and drops the duplicates.
+func uniqueSorted(units []string) []string {
+	sort.Strings(units)
+	unique := units[:0]
+	for i, unit := range units {
+		if i == 0 || unit != units[i-1] {
+			unique = append(unique, unit)
+		}
+	}
+	return unique
+}
+
+// Order groups units into levels so that every unit comes after the units it
+// depends on, directly or through units that are not part of the request.
+// Units within a level do not depend on each other and can run concurrently.
+// When reverse is set dependents come first, which is the order to destroy in.
+func (g *Graph) Order(units []string, reverse bool) ([][]string, error) {
+	edges := g.Dependencies
+	if reverse {
+		edges = g.Dependents
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	depth := make(map[string]int)
+	var stack []string
+
+	var visit func(unit string) error
+	visit = func(unit string) error {
+		switch state[unit] {
+		case visited:
+			return nil
+		case visiting:
+			for i, u := range stack {
+				if u == unit {
+					cycle := append(append([]string{}, stack[i:]...), unit)
+					return &CycleError{Cycle: cycle}
+				}
+			}
+		}
+		state[unit] = visiting
+		stack = append(stack, unit)
+		for _, next := range edges[unit] {
+			if err := visit(next); err != nil {
+				return err
+			}
+			if depth[next]+1 > depth[unit] {
+				depth[unit] = depth[next] + 1
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[unit] = visited
+		return nil
+	}
+
+	for _, unit := range units {
+		if err := visit(unit); err != nil {
+			return nil, err
+		}
+	}
+
+	byDepth := make(map[int][]string)
+	var depths []int
+	for _, unit := range units {
+		d := depth[unit]
+		if _, ok := byDepth[d]; !ok {
+			depths = append(depths, d)
+		}
+		byDepth[d] = append(byDepth[d], unit)
+	}
+	sort.Ints(depths)
+
+	levels := make([][]string, 0, len(depths))
+	for _, d := range depths {
+		sort.Strings(byDepth[d])
+		levels = append(levels, byDepth[d])
+	}
+	return levels, nil
+}
+
+// Upstream returns every unit that unit depends on, directly or transitively.
+func (g *Graph) Upstream(unit string) []string {
+	return g.walk(unit, g.Dependencies)
+}
+
+// Downstream returns every unit that depends on unit, directly or
+// transitively.
+func (g *Graph) Downstream(unit string) []string {
+	return g.walk(unit, g.Dependents)
+}
+
+func (g *Graph) walk(unit string, edges map[string][]string) []string {
+	seen := map[string]bool{unit: true}
+	var units []string
+	queue := []string{unit}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			units = append(units, next)
+			queue = append(queue, next)
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// Units returns every unit of the graph, including dependencies that live
+// outside the workspace, in lexical order.
+func (g *Graph) Units() []string {
+	seen := make(map[string]bool)
+	for unit, dependencies := range g.Dependencies {
+		seen[unit] = true
+		for _, dependency := range dependencies {
+			seen[dependency] = true
+		}
+	}
+	units := make([]string, 0, len(seen))
+	for unit := range seen {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	return units
+}
+
+// Relative returns a copy of the graph whose units are relative to root, which
+// keeps exported graphs readable.
+func (g *Graph) Relative(root string) *Graph {
+	rel := func(unit string) string {
+		if r, err := filepath.Rel(root, unit); err == nil {
+			return r
+		}
+		return unit
+	}
+	relative := &Graph{
+		Dependencies: make(map[string][]string, len(g.Dependencies)),
+		Dependents:   make(map[string][]string, len(g.Dependents)),
+	}
+	for _, pair := range []struct{ from, to map[string][]string }{
+		{g.Dependencies, relative.Dependencies},
+		{g.Dependents, relative.Dependents},
+	} {
+		for unit, edges := range pair.from {
+			converted := make([]string, 0, len(edges))
+			for _, edge := range edges {
+				converted = append(converted, rel(edge))
+			}
+			pair.to[rel(unit)] = converted
+		}
+	}
+	return relative
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Edges point from a unit to
+// the units it depends on.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph terragrunt {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, unit := range g.Units() {
+		fmt.Fprintf(&b, "  %q;\n", unit)
+	}
+	for _, unit := range g.Units() {
+		for _, dependency := range g.Dependencies[unit] {
+			fmt.Fprintf(&b, "  %q -> %q;\n", unit, dependency)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Edges point from a
+// unit to the units it depends on.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	units := g.Units()
+	ids := make(map[string]string, len(units))
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, unit := range units {
+		ids[unit] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[unit], strings.ReplaceAll(unit, `"`, "#quot;"))
+	}
+	for _, unit := range units {
+		for _, dependency := range g.Dependencies[unit] {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[unit], ids[dependency])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
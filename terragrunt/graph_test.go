@@ -0,0 +1,93 @@
+package terragrunt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testWorkspace builds a workspace of the files at the given paths, each
+// depending on the directories listed for it.
+func testWorkspace(dependencies map[string][]string) Workspace {
+	workspace := Workspace{Projects: make(map[string]*Project)}
+	for path, dirs := range dependencies {
+		workspace.addFile(File{Path: path, ProjectID: "p", RegionID: "r", StackID: path, Dependencies: dirs})
+	}
+	return workspace
+}
+
+func TestGraphDeduplicatesEdges(t *testing.T) {
+	workspace := testWorkspace(map[string][]string{
+		"app/terragrunt.hcl": {"vpc", "db", "vpc"},
+		"db/terragrunt.hcl":  {"vpc"},
+		"vpc/terragrunt.hcl": nil,
+	})
+	g := workspace.Graph()
+	if want := []string{"db", "vpc"}; !reflect.DeepEqual(g.Dependencies["app"], want) {
+		t.Errorf("dependencies of app = %v, want %v", g.Dependencies["app"], want)
+	}
+	if want := []string{"app", "db"}; !reflect.DeepEqual(g.Dependents["vpc"], want) {
+		t.Errorf("dependents of vpc = %v, want %v", g.Dependents["vpc"], want)
+	}
+}
+
+func TestGraphOrder(t *testing.T) {
+	workspace := testWorkspace(map[string][]string{
+		"app/terragrunt.hcl":   {"db", "vpc"},
+		"db/terragrunt.hcl":    {"vpc"},
+		"vpc/terragrunt.hcl":   nil,
+		"dns/terragrunt.hcl":   nil,
+		"cache/terragrunt.hcl": {"db"},
+	})
+	g := workspace.Graph()
+	tests := []struct {
+		name    string
+		units   []string
+		reverse bool
+		want    [][]string
+	}{
+		{
+			name:  "dependencies first",
+			units: []string{"app", "cache", "db", "dns", "vpc"},
+			want:  [][]string{{"dns", "vpc"}, {"db"}, {"app", "cache"}},
+		},
+		{
+			name:    "dependents first",
+			units:   []string{"app", "cache", "db", "dns", "vpc"},
+			reverse: true,
+			want:    [][]string{{"app", "cache", "dns"}, {"db"}, {"vpc"}},
+		},
+		{
+			name:  "through units left out",
+			units: []string{"app", "vpc"},
+			want:  [][]string{{"vpc"}, {"app"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			levels, err := g.Order(test.units, test.reverse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(levels, test.want) {
+				t.Errorf("got %v, want %v", levels, test.want)
+			}
+		})
+	}
+}
+
+func TestGraphOrderCycle(t *testing.T) {
+	workspace := testWorkspace(map[string][]string{
+		"a/terragrunt.hcl": {"b"},
+		"b/terragrunt.hcl": {"c"},
+		"c/terragrunt.hcl": {"a"},
+	})
+	_, err := workspace.Graph().Order([]string{"a"}, false)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("got %v, want a CycleError", err)
+	}
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(cycle.Cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycle.Cycle, want)
+	}
+}
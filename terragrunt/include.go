@@ -0,0 +1,265 @@
+package terragrunt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Merge strategies of `include` blocks.
+const (
+	ShallowMerge = "shallow"
+	DeepMerge    = "deep"
+	NoMerge      = "no_merge"
+)
+
+// Setting is a value of the effective configuration together with the file
+// it comes from.
+type Setting struct {
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+}
+
+// EffectiveConfig is the configuration of a stack once its includes are
+// merged, as terragrunt runs it.
+type EffectiveConfig struct {
+	// Files are the included files followed by the file of the stack, in
+	// the order they are merged.
+	Files        []string           `json:"files"`
+	Source       *Setting           `json:"source,omitempty"`
+	Backend      *Setting           `json:"backend,omitempty"`
+	RemoteState  map[string]Setting `json:"remote_state,omitempty"`
+	Inputs       map[string]Setting `json:"inputs,omitempty"`
+	Dependencies []Dependency       `json:"dependencies,omitempty"`
+	// DependencyPaths are the paths of the `dependencies` blocks.
+	DependencyPaths []string `json:"dependency_paths,omitempty"`
+}
+
+// ResolveConfig merges the files included by the terragrunt file at filePath
+// into its configuration. Included files are evaluated in the scope of the
+// including file, so that functions such as find_in_parent_folders() and
+// path_relative_to_include() return what terragrunt would.
+func ResolveConfig(filePath string, content []byte) (*Config, *EffectiveConfig, error) {
+	return resolveConfig(filePath, content, nil)
+}
+
+// resolveConfig is ResolveConfig recording the files and environment
+// variables read in r, when set.
+func resolveConfig(filePath string, content []byte, r *reads) (*Config, *EffectiveConfig, error) {
+	dir := absDir(filePath)
+	config, err := parseConfig(filePath, content, scope{dir: dir, reads: r})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type parent struct {
+		path     string
+		config   *Config
+		strategy string
+	}
+	var parents []parent
+	exposed := make(map[string]cty.Value)
+	for _, include := range config.Includes {
+		if include.Path == "" || IsExpression(include.Path) {
+			return config, nil, fmt.Errorf("include %q: path cannot be evaluated: %s", include.Name, include.Path)
+		}
+		path := include.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		includeContent, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			r.miss(path)
+		}
+		if err != nil {
+			return config, nil, fmt.Errorf("include %q: %v", include.Name, err)
+		}
+		r.file(path)
+		included, err := parseConfig(path, includeContent, scope{dir: dir, includeDir: filepath.Dir(path), reads: r})
+		if err != nil {
+			return config, nil, fmt.Errorf("include %q: %v", include.Name, err)
+		}
+		strategy := include.MergeStrategy
+		if strategy == "" {
+			strategy = ShallowMerge
+		}
+		parents = append(parents, parent{path: path, config: included, strategy: strategy})
+		if include.Expose {
+			exposed[include.Name] = ctyValue(map[string]interface{}{
+				"locals": included.Locals,
+				"inputs": included.Inputs,
+			})
+		}
+	}
+
+	// Exposed includes can be referenced from the file, which then needs
+	// to be evaluated again.
+	if len(exposed) > 0 {
+		if config, err = parseConfig(filePath, content, scope{dir: dir, include: exposed, reads: r}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Later includes override earlier ones, and the file overrides them all,
+	// deeply when one of them asks for it.
+	effective := &EffectiveConfig{}
+	deep := false
+	for _, parent := range parents {
+		if parent.strategy != NoMerge {
+			effective.merge(parent.path, parent.config, false)
+		}
+		deep = deep || parent.strategy == DeepMerge
+	}
+	effective.merge(filePath, config, deep)
+	return config, effective, nil
+}
+
+// merge applies the settings of config, found in path, over the settings
+// merged so far. A shallow merge replaces top-level values while a deep merge
+// combines maps and lists.
+func (e *EffectiveConfig) merge(path string, config *Config, deep bool) {
+	e.Files = append(e.Files, path)
+	if config.Terraform != nil && config.Terraform.Source != "" {
+		e.Source = &Setting{Value: config.Terraform.Source, Origin: path}
+	}
+	if config.RemoteState != nil {
+		if !deep {
+			e.RemoteState = nil
+		}
+		e.Backend = &Setting{Value: config.RemoteState.Backend, Origin: path}
+		e.RemoteState = mergeSettings(e.RemoteState, config.RemoteState.Config, path, deep)
+	}
+	e.Inputs = mergeSettings(e.Inputs, config.Inputs, path, deep)
+
+	for _, dependency := range config.Dependencies {
+		replaced := false
+		for i := range e.Dependencies {
+			if e.Dependencies[i].Name == dependency.Name {
+				e.Dependencies[i], replaced = dependency, true
+			}
+		}
+		if !replaced {
+			e.Dependencies = append(e.Dependencies, dependency)
+		}
+	}
+	if len(config.DependencyPaths) > 0 {
+		if !deep {
+			e.DependencyPaths = nil
+		}
+		e.DependencyPaths = append(e.DependencyPaths, config.DependencyPaths...)
+	}
+}
+
+// DependencyDirs returns the directories of the merged `dependency` and
+// `dependencies` blocks, resolved against dir, the directory of the stack.
+func (e *EffectiveConfig) DependencyDirs(dir string) ([]string, error) {
+	config := Config{Dependencies: e.Dependencies, DependencyPaths: e.DependencyPaths}
+	return config.DependencyDirs(dir)
+}
+
+// NamedSetting is a setting of the effective configuration with its dotted
+// name, e.g. "inputs.vpc_id".
+type NamedSetting struct {
+	Name string
+	Setting
+}
+
+// Settings lists the terraform source, remote state and inputs, each group
+// sorted by name.
+func (e *EffectiveConfig) Settings() []NamedSetting {
+	var settings []NamedSetting
+	if e.Source != nil {
+		settings = append(settings, NamedSetting{Name: "terraform.source", Setting: *e.Source})
+	}
+	if e.Backend != nil {
+		settings = append(settings, NamedSetting{Name: "remote_state.backend", Setting: *e.Backend})
+	}
+	settings = append(settings, namedSettings("remote_state.config.", e.RemoteState)...)
+	return append(settings, namedSettings("inputs.", e.Inputs)...)
+}
+
+func namedSettings(prefix string, settings map[string]Setting) []NamedSetting {
+	named := make([]NamedSetting, 0, len(settings))
+	for key, setting := range settings {
+		named = append(named, NamedSetting{Name: prefix + key, Setting: setting})
+	}
+	sort.Slice(named, func(i, j int) bool { return named[i].Name < named[j].Name })
+	return named
+}
+
+// FormatValue renders a configuration value on a single line: strings as is
+// and anything else as JSON.
+func FormatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func mergeSettings(settings map[string]Setting, values map[string]interface{}, origin string, deep bool) map[string]Setting {
+	if len(values) == 0 {
+		return settings
+	}
+	if settings == nil {
+		settings = make(map[string]Setting, len(values))
+	}
+	for key, value := range values {
+		if current, ok := settings[key]; ok && deep {
+			value = deepMerge(current.Value, value)
+		}
+		settings[key] = Setting{Value: value, Origin: origin}
+	}
+	return settings
+}
+
+// deepMerge merges the maps in override into base and appends lists, like
+// the deep merge strategy of terragrunt.
+func deepMerge(base, override interface{}) interface{} {
+	switch override := override.(type) {
+	case map[string]interface{}:
+		baseMap, ok := base.(map[string]interface{})
+		if !ok {
+			return override
+		}
+		merged := make(map[string]interface{}, len(baseMap)+len(override))
+		for key, value := range baseMap {
+			merged[key] = value
+		}
+		for key, value := range override {
+			merged[key] = deepMerge(merged[key], value)
+		}
+		return merged
+	case []interface{}:
+		if baseList, ok := base.([]interface{}); ok {
+			return append(append([]interface{}{}, baseList...), override...)
+		}
+	}
+	return override
+}
+
+// ctyValue converts a value of a Config back to cty.
+func ctyValue(value interface{}) cty.Value {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	t, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	v, err := ctyjson.Unmarshal(data, t)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	return v
+}
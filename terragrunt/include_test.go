@@ -0,0 +1,152 @@
+package terragrunt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testRootConfig = `
+locals {
+  account = "acme"
+}
+inputs = {
+  region = "us-east-1"
+  tags   = { Owner = "platform" }
+  zones  = ["a"]
+}
+`
+
+func TestResolveConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		inputs  map[string]Setting
+		// merged are the files merged, by origin.
+		merged []string
+	}{
+		{
+			name:    "shallow",
+			include: `include "root" { path = "../root.hcl" }`,
+			inputs: map[string]Setting{
+				"region": {Value: "us-east-1", Origin: "root"},
+				"tags":   {Value: map[string]interface{}{"Team": "core"}, Origin: "stack"},
+				"zones":  {Value: []interface{}{"b"}, Origin: "stack"},
+			},
+			merged: []string{"root", "stack"},
+		},
+		{
+			name: "deep",
+			include: `include "root" {
+  path           = "../root.hcl"
+  merge_strategy = "deep"
+}`,
+			inputs: map[string]Setting{
+				"region": {Value: "us-east-1", Origin: "root"},
+				"tags":   {Value: map[string]interface{}{"Owner": "platform", "Team": "core"}, Origin: "stack"},
+				"zones":  {Value: []interface{}{"a", "b"}, Origin: "stack"},
+			},
+			merged: []string{"root", "stack"},
+		},
+		{
+			name: "no_merge",
+			include: `include "root" {
+  path           = "../root.hcl"
+  merge_strategy = "no_merge"
+}`,
+			inputs: map[string]Setting{
+				"tags":  {Value: map[string]interface{}{"Team": "core"}, Origin: "stack"},
+				"zones": {Value: []interface{}{"b"}, Origin: "stack"},
+			},
+			merged: []string{"stack"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, stack := writeTestStack(t, test.include+`
+inputs = {
+  tags  = { Team = "core" }
+  zones = ["b"]
+}
+`)
+			_, effective, err := ResolveConfig(stack, mustRead(t, stack))
+			if err != nil {
+				t.Fatal(err)
+			}
+			origins := map[string]string{"root": root, "stack": stack}
+			want := make(map[string]Setting, len(test.inputs))
+			for key, setting := range test.inputs {
+				want[key] = Setting{Value: setting.Value, Origin: origins[setting.Origin]}
+			}
+			if !reflect.DeepEqual(effective.Inputs, want) {
+				t.Errorf("inputs = %#v, want %#v", effective.Inputs, want)
+			}
+			var files []string
+			for _, origin := range test.merged {
+				files = append(files, origins[origin])
+			}
+			if !reflect.DeepEqual(effective.Files, files) {
+				t.Errorf("files = %v, want %v", effective.Files, files)
+			}
+		})
+	}
+}
+
+func TestResolveConfigExposed(t *testing.T) {
+	_, stack := writeTestStack(t, `
+include "root" {
+  path   = "../root.hcl"
+  expose = true
+}
+inputs = {
+  name = "${include.root.locals.account}-app"
+}
+`)
+	config, effective, err := ResolveConfig(stack, mustRead(t, stack))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Inputs["name"]; got != "acme-app" {
+		t.Errorf("name = %v, want acme-app", got)
+	}
+	if got := effective.Inputs["name"].Value; got != "acme-app" {
+		t.Errorf("effective name = %v, want acme-app", got)
+	}
+}
+
+func TestResolveConfigMissingInclude(t *testing.T) {
+	_, stack := writeTestStack(t, `include "root" { path = "../missing.hcl" }`)
+	config, effective, err := ResolveConfig(stack, mustRead(t, stack))
+	if err == nil || config == nil || effective != nil {
+		t.Errorf("got config %v, effective %v, error %v", config, effective, err)
+	}
+}
+
+// writeTestStack writes testRootConfig and a stack below it with content, and
+// returns their paths.
+func writeTestStack(t *testing.T, content string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.hcl")
+	stack := filepath.Join(dir, "app", "terragrunt.hcl")
+	if err := os.WriteFile(root, []byte(testRootConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(stack), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stack, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root, stack
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
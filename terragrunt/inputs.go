@@ -0,0 +1,63 @@
+package terragrunt
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Inputs returns the inputs of the file once its includes are merged, or its
+// own inputs when they could not be resolved.
+func (f File) Inputs() map[string]interface{} {
+	if f.Effective != nil {
+		inputs := make(map[string]interface{}, len(f.Effective.Inputs))
+		for key, setting := range f.Effective.Inputs {
+			inputs[key] = setting.Value
+		}
+		return inputs
+	}
+	if f.Config != nil {
+		return f.Config.Inputs
+	}
+	return nil
+}
+
+// Flatten returns the leaves of values keyed by their path, e.g. "tags.Team"
+// or "ports[0]". Empty maps and lists are leaves too.
+func Flatten(values map[string]interface{}) map[string]interface{} {
+	leaves := make(map[string]interface{})
+	for key, value := range values {
+		flatten(key, value, leaves)
+	}
+	return leaves
+}
+
+func flatten(path string, value interface{}, leaves map[string]interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			leaves[path] = value
+		}
+		for key, child := range value {
+			flatten(path+"."+key, child, leaves)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			leaves[path] = value
+		}
+		for i, child := range value {
+			flatten(fmt.Sprintf("%s[%d]", path, i), child, leaves)
+		}
+	default:
+		leaves[path] = value
+	}
+}
+
+// SortedKeys returns the keys of m in order.
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
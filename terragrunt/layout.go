@@ -0,0 +1,220 @@
+package terragrunt
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Layout describes where terragrunt units live and how their directories map
+// to the Project/Region/Stack hierarchy.
+//
+// Path is a template of directory segments. A segment is either a literal
+// directory name, `*` to match any directory, or `{name}` to capture the
+// directory under that name, e.g. `live/{account}/{env}/{region}/{component}`.
+// Project, Region and Stack are templates built from the captured names, e.g.
+// `{account}-{env}`. When left empty they use the segment of the same name if
+// there is one; otherwise Project is the first captured segment, Stack the
+// last and Region everything in between.
+type Layout struct {
+	Path    string `yaml:"path"`
+	Project string `yaml:"project"`
+	Region  string `yaml:"region"`
+	Stack   string `yaml:"stack"`
+}
+
+var DefaultLayout = Layout{Path: "workspaces/{project}/{region}/{stack}"}
+
+var placeholder = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+type layoutSegment struct {
+	literal string
+	name    string
+	any     bool
+}
+
+func (l Layout) segments() ([]layoutSegment, error) {
+	path := strings.Trim(filepath.ToSlash(l.Path), "/")
+	if path == "" {
+		return nil, fmt.Errorf("layout path is empty")
+	}
+
+	var segments []layoutSegment
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(path, "/") {
+		switch {
+		case part == "*":
+			segments = append(segments, layoutSegment{any: true})
+		case placeholder.MatchString(part):
+			match := placeholder.FindStringSubmatch(part)
+			if match[0] != part {
+				return nil, fmt.Errorf("layout segment %q must be a literal, * or a single {name}", part)
+			}
+			if seen[match[1]] {
+				return nil, fmt.Errorf("layout segment {%s} is used more than once", match[1])
+			}
+			seen[match[1]] = true
+			segments = append(segments, layoutSegment{name: match[1]})
+		case strings.ContainsAny(part, "{}"):
+			return nil, fmt.Errorf("layout segment %q must be a literal, * or a single {name}", part)
+		default:
+			segments = append(segments, layoutSegment{literal: part})
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("layout path %q has no {name} segments", l.Path)
+	}
+	return segments, nil
+}
+
+// segmentNames returns the names of the captured segments, in order.
+func (l Layout) segmentNames() []string {
+	segments, err := l.segments()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, segment := range segments {
+		if segment.name != "" {
+			names = append(names, segment.name)
+		}
+	}
+	return names
+}
+
+// levels returns the Project, Region and Stack templates, filling in the
+// defaults for the ones that are not set.
+func (l Layout) levels(segments []layoutSegment) (string, string, string) {
+	var names []string
+	known := make(map[string]bool)
+	for _, segment := range segments {
+		if segment.name != "" {
+			names = append(names, segment.name)
+			known[segment.name] = true
+		}
+	}
+
+	level := func(configured, name string, fallback func() string) string {
+		switch {
+		case configured != "":
+			return configured
+		case known[name]:
+			return "{" + name + "}"
+		}
+		return fallback()
+	}
+	project := level(l.Project, "project", func() string { return "{" + names[0] + "}" })
+	stack := level(l.Stack, "stack", func() string { return "{" + names[len(names)-1] + "}" })
+	region := level(l.Region, "region", func() string {
+		if len(names) < 3 {
+			return ""
+		}
+		parts := make([]string, 0, len(names)-2)
+		for _, name := range names[1 : len(names)-1] {
+			parts = append(parts, "{"+name+"}")
+		}
+		return strings.Join(parts, "/")
+	})
+	return project, region, stack
+}
+
+// Validate reports whether the layout can be used to build a workspace.
+func (l Layout) Validate() error {
+	segments, err := l.segments()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, segment := range segments {
+		known[segment.name] = true
+	}
+	for _, level := range []string{l.Project, l.Region, l.Stack} {
+		for _, match := range placeholder.FindAllStringSubmatch(level, -1) {
+			if !known[match[1]] {
+				return fmt.Errorf("layout references {%s}, which is not a segment of %q", match[1], l.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// placement is where a file sits in the hierarchy according to a layout.
+type placement struct {
+	project  string
+	region   string
+	stack    string
+	segments map[string]string
+}
+
+// match places the terragrunt file at filePath, which lives under rootDir.
+// Templates that start with a named segment are matched from rootDir, while
+// templates that start with a literal directory may match anywhere in the
+// path, like the original `workspaces` convention.
+func (l Layout) match(rootDir, filePath string) (placement, bool) {
+	segments, err := l.segments()
+	if err != nil {
+		return placement{}, false
+	}
+
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return placement{}, false
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return placement{}, false
+	}
+	dirs := splitPath(filepath.Dir(absPath))
+	rootLength := len(splitPath(absRoot))
+
+	first := rootLength
+	if segments[0].literal != "" {
+		first = 0
+	}
+	for start := first; start+len(segments) <= len(dirs); start++ {
+		values, ok := matchSegments(segments, dirs[start:start+len(segments)])
+		if !ok {
+			continue
+		}
+		project, region, stack := l.levels(segments)
+		return placement{
+			project:  expand(project, values),
+			region:   expand(region, values),
+			stack:    expand(stack, values),
+			segments: values,
+		}, true
+	}
+	return placement{}, false
+}
+
+func matchSegments(segments []layoutSegment, dirs []string) (map[string]string, bool) {
+	values := make(map[string]string)
+	for i, segment := range segments {
+		switch {
+		case segment.literal != "":
+			if dirs[i] != segment.literal {
+				return nil, false
+			}
+		case segment.name != "":
+			values[segment.name] = dirs[i]
+		}
+	}
+	return values, true
+}
+
+func expand(template string, values map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		return values[match[1:len(match)-1]]
+	})
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,107 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayoutMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		path   string
+		ok     bool
+		want   placement
+	}{
+		{
+			name:   "default",
+			layout: DefaultLayout,
+			path:   "/repo/workspaces/acme/us-east-1/vpc/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "acme", region: "us-east-1", stack: "vpc",
+				segments: map[string]string{"project": "acme", "region": "us-east-1", "stack": "vpc"}},
+		},
+		{
+			name:   "literal prefix anywhere in the path",
+			layout: DefaultLayout,
+			path:   "/repo/infra/workspaces/acme/us-east-1/vpc/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "acme", region: "us-east-1", stack: "vpc",
+				segments: map[string]string{"project": "acme", "region": "us-east-1", "stack": "vpc"}},
+		},
+		{
+			name:   "wildcard segment",
+			layout: Layout{Path: "live/{account}/*/{region}/{component}"},
+			path:   "/repo/live/prod/team-a/eu-west-1/db/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "prod", region: "eu-west-1", stack: "db",
+				segments: map[string]string{"account": "prod", "region": "eu-west-1", "component": "db"}},
+		},
+		{
+			name:   "wildcard first",
+			layout: Layout{Path: "*/{project}/{stack}"},
+			path:   "/repo/anything/acme/vpc/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "acme", stack: "vpc",
+				segments: map[string]string{"project": "acme", "stack": "vpc"}},
+		},
+		{
+			name:   "templates",
+			layout: Layout{Path: "live/{account}/{env}/{region}/{component}", Project: "{account}-{env}"},
+			path:   "/repo/live/acme/prod/us-east-1/api/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "acme-prod", region: "us-east-1", stack: "api",
+				segments: map[string]string{"account": "acme", "env": "prod", "region": "us-east-1", "component": "api"}},
+		},
+		{
+			name:   "region from the segments in between",
+			layout: Layout{Path: "{org}/{env}/{zone}/{app}"},
+			path:   "/repo/acme/prod/eu/api/terragrunt.hcl",
+			ok:     true,
+			want: placement{project: "acme", region: "prod/eu", stack: "api",
+				segments: map[string]string{"org": "acme", "env": "prod", "zone": "eu", "app": "api"}},
+		},
+		{
+			name:   "literal mismatch",
+			layout: DefaultLayout,
+			path:   "/repo/modules/acme/us-east-1/vpc/terragrunt.hcl",
+		},
+		{
+			name:   "too shallow",
+			layout: Layout{Path: "live/*/{region}/{component}"},
+			path:   "/repo/live/prod/vpc/terragrunt.hcl",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.layout.match("/repo", test.path)
+			if ok != test.ok {
+				t.Fatalf("ok = %v, want %v", ok, test.ok)
+			}
+			if ok && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	tests := []struct {
+		layout Layout
+		valid  bool
+	}{
+		{DefaultLayout, true},
+		{Layout{Path: "live/*/{region}/{component}"}, true},
+		{Layout{Path: ""}, false},
+		{Layout{Path: "live/*/*"}, false},
+		{Layout{Path: "live/{a}-{b}"}, false},
+		{Layout{Path: "live/{a}/{a}"}, false},
+		{Layout{Path: "live/x{a}"}, false},
+		{Layout{Path: "{project}/{stack}", Region: "{zone}"}, false},
+	}
+	for _, test := range tests {
+		if err := test.layout.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", test.layout, err, test.valid)
+		}
+	}
+}
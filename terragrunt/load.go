@@ -0,0 +1,512 @@
+package terragrunt
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DiagnosticKind classifies the problems found while loading a workspace.
+type DiagnosticKind string
+
+const (
+	// Skipped files are outside of the layout of the workspace.
+	Skipped DiagnosticKind = "skipped"
+	// Unreadable files and directories could not be read and are left out.
+	Unreadable DiagnosticKind = "unreadable"
+	// ParseError files are not valid HCL and have no configuration.
+	ParseError DiagnosticKind = "parse error"
+	// IncludeError files have a configuration but no effective one since
+	// their includes could not be resolved.
+	IncludeError DiagnosticKind = "include error"
+	// DependencyError files have dependencies that could not be evaluated.
+	DependencyError DiagnosticKind = "dependency error"
+	// CacheError is a scan cache that could not be saved.
+	CacheError DiagnosticKind = "cache error"
+)
+
+// ignoredDirs are never walked. Terragrunt and terraform caches hold copies
+// of the units, which would otherwise be loaded twice.
+var ignoredDirs = map[string]bool{
+	".terragrunt-cache": true,
+	".terraform":        true,
+	".git":              true,
+}
+
+// Diagnostic is a problem with a path found while loading a workspace. None of
+// them stops the loading: the workspace holds everything else.
+type Diagnostic struct {
+	Kind    DiagnosticKind `json:"kind"`
+	Path    string         `json:"path"`
+	Message string         `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Path, d.Kind, d.Message)
+}
+
+// Option configures Load.
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	layout         Layout
+	ordering       Ordering
+	include        []string
+	exclude        []string
+	followSymlinks bool
+	maxDepth       int
+	cacheDir       string
+}
+
+// WithLayout places the files in the hierarchy according to layout,
+// DefaultLayout otherwise.
+func WithLayout(layout Layout) Option {
+	return func(o *loadOptions) { o.layout = layout }
+}
+
+// WithOrdering sets the ordering of the workspace.
+func WithOrdering(ordering Ordering) Option {
+	return func(o *loadOptions) { o.ordering = ordering }
+}
+
+// WithInclude only loads the units whose directory, relative to the root,
+// matches one of globs. Globs use the syntax of path.Match, and a `**`
+// segment matches any number of directories, e.g. `live/prod/**`.
+func WithInclude(globs ...string) Option {
+	return func(o *loadOptions) { o.include = append(o.include, globs...) }
+}
+
+// WithExclude skips the directories, relative to the root, that match one of
+// globs, along with everything underneath them.
+func WithExclude(globs ...string) Option {
+	return func(o *loadOptions) { o.exclude = append(o.exclude, globs...) }
+}
+
+// WithFollowSymlinks walks into symbolic links to directories, which are
+// skipped otherwise. Links that lead back to a directory being walked are
+// ignored.
+func WithFollowSymlinks(follow bool) Option {
+	return func(o *loadOptions) { o.followSymlinks = follow }
+}
+
+// WithMaxDepth stops descending more than depth directories below the root.
+// Zero, the default, does not limit the depth.
+func WithMaxDepth(depth int) Option {
+	return func(o *loadOptions) { o.maxDepth = depth }
+}
+
+// WithCache keeps the parsed files in dir between loads. Only the files
+// modified since the last load, or whose includes were, are parsed again.
+func WithCache(dir string) Option {
+	return func(o *loadOptions) { o.cacheDir = dir }
+}
+
+// Load builds the workspace from the terragrunt files under root. Problems
+// with single files are returned as diagnostics, while an invalid option, an
+// unreadable root or the cancellation of ctx fail the whole load.
+func Load(ctx context.Context, root string, opts ...Option) (Workspace, []Diagnostic, error) {
+	options := loadOptions{layout: DefaultLayout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.validate(); err != nil {
+		return Workspace{}, nil, err
+	}
+	if _, err := os.Stat(root); err != nil {
+		return Workspace{}, nil, err
+	}
+
+	l := loader{ctx: ctx, root: root, options: options}
+	files, err := l.findFiles()
+	if err != nil {
+		return Workspace{}, l.diagnostics, err
+	}
+	workspace := Workspace{Root: root, Layout: options.layout, Ordering: options.ordering, Projects: make(map[string]*Project)}
+	var cache *scanCache
+	if options.cacheDir != "" {
+		cache = openCache(options.cacheDir, root)
+	}
+	units := workspace.parseFiles(ctx, files, cache)
+	if err := ctx.Err(); err != nil {
+		return Workspace{}, l.diagnostics, err
+	}
+
+	entries := make(map[string]cacheEntry, len(units))
+	changed := false
+	for _, unit := range units {
+		l.diagnostics = append(l.diagnostics, unit.diagnostics...)
+		if unit.file != nil {
+			workspace.addFile(*unit.file)
+		}
+		if unit.entry != nil {
+			entries[unit.path] = *unit.entry
+			changed = changed || !unit.cached
+		}
+	}
+	if cache != nil && (changed || len(entries) != len(cache.entries)) {
+		if err := cache.save(entries); err != nil {
+			l.diagnose(CacheError, cache.path, err)
+		}
+	}
+	return workspace, l.diagnostics, nil
+}
+
+func (o loadOptions) validate() error {
+	if err := o.layout.Validate(); err != nil {
+		return err
+	}
+	if err := o.ordering.Validate(); err != nil {
+		return err
+	}
+	for _, glob := range append(append([]string{}, o.include...), o.exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %v", glob, err)
+		}
+	}
+	if o.maxDepth < 0 {
+		return fmt.Errorf("max depth must not be negative, got %d", o.maxDepth)
+	}
+	return nil
+}
+
+type loader struct {
+	ctx     context.Context
+	root    string
+	options loadOptions
+
+	// slots bounds the directories walked concurrently.
+	slots       chan struct{}
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	files       []string
+	diagnostics []Diagnostic
+	err         error
+}
+
+func (l *loader) diagnose(kind DiagnosticKind, path string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.diagnostics = append(l.diagnostics, Diagnostic{Kind: kind, Path: path, Message: err.Error()})
+}
+
+func (l *loader) addPath(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.files = append(l.files, path)
+}
+
+func (l *loader) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// findFiles returns the terragrunt files under the root, sorted by path.
+// Directories are walked concurrently.
+func (l *loader) findFiles() ([]string, error) {
+	real, err := filepath.EvalSymlinks(l.root)
+	if err != nil {
+		return nil, err
+	}
+	l.slots = make(chan struct{}, runtime.NumCPU())
+	if err := l.walk(real, l.root, map[string]bool{real: true}); err != nil {
+		l.fail(err)
+	}
+	l.wg.Wait()
+	if l.err != nil {
+		return nil, l.err
+	}
+	sort.Strings(l.files)
+	sort.SliceStable(l.diagnostics, func(i, j int) bool { return l.diagnostics[i].Path < l.diagnostics[j].Path })
+	return l.files, nil
+}
+
+// walk collects the files under dir, a path without symbolic links that is
+// reached through logical, so that the files found behind links keep the path
+// of the link. Subdirectories are handed to another goroutine when a slot is
+// free, and walked in place otherwise. visited holds the targets of the links
+// followed to reach dir, which must not be entered again.
+func (l *loader) walk(dir, logical string, visited map[string]bool) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if ctxErr := l.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		logicalPath := logical
+		if rel, relErr := filepath.Rel(dir, path); relErr == nil {
+			logicalPath = filepath.Join(logical, rel)
+		}
+		if err != nil {
+			if logicalPath == filepath.Clean(l.root) {
+				return err
+			}
+			l.diagnose(Unreadable, logicalPath, err)
+			return nil
+		}
+		rel := l.relative(logicalPath)
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return l.walkSymlink(path, logicalPath, rel, visited)
+		}
+		if !entry.IsDir() {
+			if l.isUnit(entry.Name(), rel) {
+				l.addPath(logicalPath)
+			}
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		if l.skipDir(entry.Name(), rel) {
+			return filepath.SkipDir
+		}
+		select {
+		case l.slots <- struct{}{}:
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer func() { <-l.slots }()
+				if err := l.walk(path, logicalPath, visited); err != nil {
+					l.fail(err)
+				}
+			}()
+			return filepath.SkipDir
+		default:
+			return nil
+		}
+	})
+}
+
+func (l *loader) walkSymlink(path, logicalPath, rel string, visited map[string]bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		l.diagnose(Unreadable, logicalPath, err)
+		return nil
+	}
+	if !info.IsDir() {
+		if l.isUnit(filepath.Base(path), rel) {
+			l.addPath(logicalPath)
+		}
+		return nil
+	}
+	if !l.options.followSymlinks || l.skipDir(filepath.Base(path), rel) {
+		return nil
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		l.diagnose(Unreadable, logicalPath, err)
+		return nil
+	}
+	// A link to a directory holding the link, or to a directory already
+	// entered on the way, e.g. two links pointing at each other, would be
+	// walked forever.
+	if parent := filepath.Dir(path); parent == real || strings.HasPrefix(parent, real+string(filepath.Separator)) || containsDir(visited, real) {
+		l.diagnose(Skipped, logicalPath, fmt.Errorf("symbolic link loops back to %s", real))
+		return nil
+	}
+	next := make(map[string]bool, len(visited)+1)
+	for dir := range visited {
+		next[dir] = true
+	}
+	next[real] = true
+	return l.walk(real, logicalPath, next)
+}
+
+// containsDir reports whether dir is one of dirs or holds one of them.
+func containsDir(dirs map[string]bool, dir string) bool {
+	for visited := range dirs {
+		if visited == dir || strings.HasPrefix(visited, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// relative returns path relative to the root, with slashes.
+func (l *loader) relative(path string) string {
+	rel, err := filepath.Rel(l.root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// skipDir reports whether the directory at rel is not walked: ignored and
+// hidden directories, excluded ones and the ones deeper than the maximum
+// depth.
+func (l *loader) skipDir(name, rel string) bool {
+	if ignoredDirs[name] || strings.HasPrefix(name, ".") {
+		return true
+	}
+	if l.options.maxDepth > 0 && strings.Count(rel, "/")+1 > l.options.maxDepth {
+		return true
+	}
+	return matchesAny(l.options.exclude, rel)
+}
+
+// isUnit reports whether the file at rel is a terragrunt file to load.
+func (l *loader) isUnit(name, rel string) bool {
+	if !strings.HasSuffix(name, "terragrunt.hcl") {
+		return false
+	}
+	dir := path.Dir(rel)
+	if matchesAny(l.options.exclude, dir) {
+		return false
+	}
+	return len(l.options.include) == 0 || matchesAny(l.options.include, dir)
+}
+
+func matchesAny(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if matchGlob(strings.Split(glob, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches the segments of a path against the segments of a glob,
+// where `**` matches any number of segments.
+func matchGlob(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(glob[0], segments[0]); !matched {
+		return false
+	}
+	return matchGlob(glob[1:], segments[1:])
+}
+
+// loadedFile is the outcome of loading a single file.
+type loadedFile struct {
+	path string
+	// file is nil for the files left out of the workspace.
+	file        *File
+	diagnostics []Diagnostic
+	// entry is nil for the files that are not cached.
+	entry  *cacheEntry
+	cached bool
+}
+
+// parseFiles loads files concurrently, in the order given.
+func (h *Workspace) parseFiles(ctx context.Context, files []string, cache *scanCache) []loadedFile {
+	loaded := make([]loadedFile, len(files))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU() && w < len(files); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if ctx.Err() == nil {
+					loaded[i] = h.parseFile(files[i], cache)
+				}
+			}
+		}()
+	}
+	for i := range files {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return loaded
+}
+
+// parseFile places and parses the file at filePath, from the cache when it
+// did not change. The content of cached files is left to be read when
+// needed.
+func (h *Workspace) parseFile(filePath string, cache *scanCache) loadedFile {
+	place, ok := h.Layout.match(h.Root, filePath)
+	if !ok {
+		return loadedFile{path: filePath, diagnostics: []Diagnostic{{Kind: Skipped, Path: filePath, Message: fmt.Sprintf("outside of layout %q", h.Layout.Path)}}}
+	}
+	file := File{Path: filePath, RegionID: place.region, ProjectID: place.project, StackID: place.stack, Segments: place.segments, content: &lazyContent{}}
+	if cache != nil {
+		if entry, ok := cache.lookup(filePath); ok {
+			file.Config, file.Effective, file.Dependencies = entry.Config, entry.Effective, entry.Dependencies
+			return loadedFile{path: filePath, file: &file, diagnostics: entry.Diagnostics, entry: &entry, cached: true}
+		}
+	}
+
+	// Stat before reading, so that a change in between invalidates the
+	// entry next time.
+	stat, err := statFile(filePath)
+	if err != nil {
+		return loadedFile{path: filePath, diagnostics: []Diagnostic{{Kind: Unreadable, Path: filePath, Message: err.Error()}}}
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return loadedFile{path: filePath, diagnostics: []Diagnostic{{Kind: Unreadable, Path: filePath, Message: err.Error()}}}
+	}
+	file.content.set(string(content))
+
+	var diagnostics []Diagnostic
+	r := newReads()
+	config, effective, err := resolveConfig(filePath, content, r)
+	switch {
+	case config == nil:
+		diagnostics = append(diagnostics, Diagnostic{Kind: ParseError, Path: filePath, Message: err.Error()})
+	case effective == nil:
+		diagnostics = append(diagnostics, Diagnostic{Kind: IncludeError, Path: filePath, Message: err.Error()})
+		file.Dependencies, err = config.DependencyDirs(filepath.Dir(filePath))
+	default:
+		file.Dependencies, err = effective.DependencyDirs(filepath.Dir(filePath))
+	}
+	if config != nil && err != nil {
+		diagnostics = append(diagnostics, Diagnostic{Kind: DependencyError, Path: filePath, Message: err.Error()})
+	}
+	file.Config, file.Effective = config, effective
+
+	loaded := loadedFile{path: filePath, file: &file, diagnostics: diagnostics}
+	// Files whose includes are missing are parsed again until they resolve.
+	if config != nil && effective == nil {
+		return loaded
+	}
+	entry := cacheEntry{Files: []cachedFile{stat}, Config: config, Effective: effective, Dependencies: file.Dependencies, Diagnostics: diagnostics}
+	for _, path := range sortedPaths(r.files) {
+		if path == filePath {
+			continue
+		}
+		stat, err := statFile(path)
+		if err != nil {
+			return loaded
+		}
+		entry.Files = append(entry.Files, stat)
+	}
+	entry.Missing = sortedPaths(r.missing)
+	for name, value := range r.env {
+		env := cachedEnv{Name: name, Set: value != nil}
+		if value != nil {
+			env.Value = *value
+		}
+		entry.Env = append(entry.Env, env)
+	}
+	sort.Slice(entry.Env, func(i, j int) bool { return entry.Env[i].Name < entry.Env[j].Name })
+	loaded.entry = &entry
+	return loaded
+}
+
+// addFile places file in the hierarchy.
+func (h *Workspace) addFile(file File) {
+	_, _, stack := h.fetchOrCreateHierarchy(file.ProjectID, file.RegionID, file.StackID)
+	stack.Files = append(stack.Files, file)
+}
@@ -0,0 +1,97 @@
+package terragrunt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSymlinkLoops(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(root, "workspaces", "p", "r", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unit := filepath.Join(root, "workspaces", "p", "r", "a", "terragrunt.hcl")
+	if err := os.WriteFile(unit, []byte("inputs = {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"workspaces/p/r/a/to-b":   "../b",
+		"workspaces/p/r/b/to-a":   "../a",
+		"workspaces/p/r/a/parent": "..",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skip(err)
+		}
+	}
+
+	workspace, diagnostics, err := Load(context.Background(), root, WithFollowSymlinks(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped int
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Kind == Skipped {
+			skipped++
+		}
+	}
+	if skipped == 0 {
+		t.Errorf("no loop reported in %v", diagnostics)
+	}
+	// The unit is found directly and through b/to-a, never endlessly.
+	if files := workspace.Files(); len(files) == 0 || len(files) > 4 {
+		t.Errorf("got %d files", len(files))
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "workspaces", "p", "r", "s")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	unit := filepath.Join(dir, "terragrunt.hcl")
+	content := `inputs = {
+  parent = find_in_parent_folders("root.hcl", "none")
+  env    = get_env("TERRAGRUNT_VISION_TEST", "unset")
+}
+`
+	if err := os.WriteFile(unit, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workspace := Workspace{Root: root, Layout: DefaultLayout}
+
+	tests := map[string]func(t *testing.T) error{
+		"parent created": func(t *testing.T) error {
+			parent := filepath.Join(root, "root.hcl")
+			t.Cleanup(func() { os.Remove(parent) })
+			return os.WriteFile(parent, nil, 0644)
+		},
+		"env set": func(t *testing.T) error {
+			t.Setenv("TERRAGRUNT_VISION_TEST", "set")
+			return nil
+		},
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			loaded := workspace.parseFile(unit, nil)
+			if loaded.entry == nil {
+				t.Fatal("no cache entry")
+			}
+			cache := &scanCache{entries: map[string]cacheEntry{unit: *loaded.entry}}
+			if _, ok := cache.lookup(unit); !ok {
+				t.Fatal("entry invalid before the change")
+			}
+			if err := change(t); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := cache.lookup(unit); ok {
+				t.Error("entry still valid after the change")
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package terragrunt
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Version statuses of a stack within its module.
+const (
+	VersionLatest      = "latest"
+	VersionBehind      = "behind"
+	VersionUnpinned    = "unpinned"
+	VersionUnversioned = "unversioned"
+)
+
+// Module groups the stacks whose terraform source points at the same module,
+// whatever version they pin.
+type Module struct {
+	Source string `json:"source"`
+	// Latest is the highest semantic version pinned by a stack, and
+	// MostCommon the ref pinned by most stacks.
+	Latest     string          `json:"latest,omitempty"`
+	MostCommon string          `json:"most_common,omitempty"`
+	Versions   []ModuleVersion `json:"versions"`
+	Stacks     []ModuleStack   `json:"stacks"`
+}
+
+type ModuleVersion struct {
+	Ref    string `json:"ref"`
+	Stacks int    `json:"stacks"`
+}
+
+type ModuleStack struct {
+	Path  string `json:"path"`
+	Stack string `json:"stack"`
+	Ref   string `json:"ref"`
+	// Status is one of VersionLatest, VersionBehind, VersionUnpinned, for
+	// sources without a ref, or VersionUnversioned, for refs that are not
+	// semantic versions such as branches and commits.
+	Status string `json:"status"`
+}
+
+// SplitSource separates the version pinned by a terraform source, from its
+// `ref` or, for registry modules, `version` query parameter, from the rest of
+// the source.
+func SplitSource(source string) (module, ref string) {
+	base, query, ok := strings.Cut(source, "?")
+	if !ok {
+		return source, ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return source, ""
+	}
+	for _, key := range []string{"ref", "version"} {
+		if ref = values.Get(key); ref != "" {
+			values.Del(key)
+			break
+		}
+	}
+	if rest := values.Encode(); rest != "" {
+		base += "?" + rest
+	}
+	return base, ref
+}
+
+// Modules groups the stacks of the workspace by the module of their
+// effective terraform source, sorted by source.
+func (h *Workspace) Modules() []Module {
+	bySource := make(map[string]*Module)
+	var sources []string
+	for _, file := range h.Files() {
+		source := file.Source()
+		if source == "" {
+			continue
+		}
+		module, ref := SplitSource(source)
+		if _, ok := bySource[module]; !ok {
+			bySource[module] = &Module{Source: module}
+			sources = append(sources, module)
+		}
+		bySource[module].Stacks = append(bySource[module].Stacks, ModuleStack{
+			Path:  file.Path,
+			Stack: file.ProjectID + "/" + file.RegionID + "/" + file.StackID,
+			Ref:   ref,
+		})
+	}
+
+	sort.Strings(sources)
+	modules := make([]Module, 0, len(sources))
+	for _, source := range sources {
+		module := bySource[source]
+		module.summarize()
+		modules = append(modules, *module)
+	}
+	return modules
+}
+
+// Source returns the terraform source of the file once its includes are
+// merged, or an empty string when it has none.
+func (f File) Source() string {
+	if f.Effective != nil {
+		if f.Effective.Source != nil {
+			source, _ := f.Effective.Source.Value.(string)
+			return source
+		}
+		return ""
+	}
+	if f.Config != nil && f.Config.Terraform != nil {
+		return f.Config.Terraform.Source
+	}
+	return ""
+}
+
+func (m *Module) summarize() {
+	counts := make(map[string]int)
+	for _, stack := range m.Stacks {
+		counts[stack.Ref]++
+		if version := canonicalVersion(stack.Ref); version != "" {
+			if m.Latest == "" || semver.Compare(version, canonicalVersion(m.Latest)) > 0 {
+				m.Latest = stack.Ref
+			}
+		}
+	}
+
+	for ref, count := range counts {
+		m.Versions = append(m.Versions, ModuleVersion{Ref: ref, Stacks: count})
+	}
+	// Newest versions first, then the refs that are not versions.
+	sort.Slice(m.Versions, func(i, j int) bool {
+		a, b := canonicalVersion(m.Versions[i].Ref), canonicalVersion(m.Versions[j].Ref)
+		if c := semver.Compare(a, b); c != 0 {
+			return c > 0
+		}
+		return m.Versions[i].Ref < m.Versions[j].Ref
+	})
+	for _, version := range m.Versions {
+		if version.Ref != "" && (m.MostCommon == "" || version.Stacks > counts[m.MostCommon]) {
+			m.MostCommon = version.Ref
+		}
+	}
+
+	for i := range m.Stacks {
+		stack := &m.Stacks[i]
+		switch {
+		case stack.Ref == "":
+			stack.Status = VersionUnpinned
+		case canonicalVersion(stack.Ref) == "":
+			stack.Status = VersionUnversioned
+		case semver.Compare(canonicalVersion(stack.Ref), canonicalVersion(m.Latest)) < 0:
+			stack.Status = VersionBehind
+		default:
+			stack.Status = VersionLatest
+		}
+	}
+	sort.SliceStable(m.Stacks, func(i, j int) bool { return m.Stacks[i].Stack < m.Stacks[j].Stack })
+}
+
+// canonicalVersion returns ref as a semantic version with a leading "v", or
+// an empty string when ref is not a version.
+func canonicalVersion(ref string) string {
+	if !strings.HasPrefix(ref, "v") {
+		ref = "v" + ref
+	}
+	if !semver.IsValid(ref) {
+		return ""
+	}
+	return ref
+}
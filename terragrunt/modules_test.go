@@ -0,0 +1,74 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSource(t *testing.T) {
+	tests := []struct {
+		source string
+		module string
+		ref    string
+	}{
+		{"git::https://github.com/org/modules.git//vpc?ref=v1.2.0", "git::https://github.com/org/modules.git//vpc", "v1.2.0"},
+		{"git::https://github.com/org/modules.git//vpc?depth=1&ref=main", "git::https://github.com/org/modules.git//vpc?depth=1", "main"},
+		{"tfr:///terraform-aws-modules/vpc/aws?version=5.1.0", "tfr:///terraform-aws-modules/vpc/aws", "5.1.0"},
+		{"../modules/vpc", "../modules/vpc", ""},
+		{"git::https://github.com/org/modules.git//vpc?depth=1", "git::https://github.com/org/modules.git//vpc?depth=1", ""},
+	}
+	for _, test := range tests {
+		module, ref := SplitSource(test.source)
+		if module != test.module || ref != test.ref {
+			t.Errorf("SplitSource(%q) = %q, %q, want %q, %q", test.source, module, ref, test.module, test.ref)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	sources := map[string]string{
+		"a": "git::https://github.com/org/modules.git//vpc?ref=v1.10.0",
+		"b": "git::https://github.com/org/modules.git//vpc?ref=v1.9.0",
+		"c": "git::https://github.com/org/modules.git//vpc?ref=v1.9.0",
+		"d": "git::https://github.com/org/modules.git//vpc?ref=main",
+		"e": "git::https://github.com/org/modules.git//vpc",
+		"f": "",
+	}
+	workspace := Workspace{Projects: make(map[string]*Project)}
+	for stack, source := range sources {
+		file := File{Path: stack + "/terragrunt.hcl", ProjectID: "p", RegionID: "r", StackID: stack, Config: &Config{}}
+		if source != "" {
+			file.Config.Terraform = &Terraform{Source: source}
+		}
+		workspace.addFile(file)
+	}
+
+	modules := workspace.Modules()
+	if len(modules) != 1 {
+		t.Fatalf("got %d modules, want 1", len(modules))
+	}
+	module := modules[0]
+	if module.Latest != "v1.10.0" || module.MostCommon != "v1.9.0" {
+		t.Errorf("latest %q, most common %q", module.Latest, module.MostCommon)
+	}
+	wantVersions := []ModuleVersion{{"v1.10.0", 1}, {"v1.9.0", 2}, {"", 1}, {"main", 1}}
+	if !reflect.DeepEqual(module.Versions, wantVersions) {
+		t.Errorf("versions = %v, want %v", module.Versions, wantVersions)
+	}
+
+	tests := []struct {
+		stack  string
+		status string
+	}{
+		{"a", VersionLatest},
+		{"b", VersionBehind},
+		{"c", VersionBehind},
+		{"d", VersionUnversioned},
+		{"e", VersionUnpinned},
+	}
+	for i, test := range tests {
+		if stack := module.Stacks[i]; stack.Stack != "p/r/"+test.stack || stack.Status != test.status {
+			t.Errorf("stack %d = %s %s, want p/r/%s %s", i, stack.Stack, stack.Status, test.stack, test.status)
+		}
+	}
+}
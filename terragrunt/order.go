@@ -0,0 +1,132 @@
+package terragrunt
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Order sorts the names of a level of the hierarchy. Names matching a glob of
+// First come first and names matching a glob of Last come last, in the order
+// of the globs, e.g. `last: [prod]` to keep production at the bottom. The
+// other names, and names matching the same glob, are sorted naturally.
+type Order struct {
+	First []string `yaml:"first"`
+	Last  []string `yaml:"last"`
+}
+
+// Ordering holds the order of each level of the hierarchy.
+type Ordering struct {
+	Projects Order `yaml:"projects"`
+	Regions  Order `yaml:"regions"`
+	Stacks   Order `yaml:"stacks"`
+}
+
+// Validate checks the globs of every level.
+func (o Ordering) Validate() error {
+	levels := []struct {
+		name  string
+		order Order
+	}{{"projects", o.Projects}, {"regions", o.Regions}, {"stacks", o.Stacks}}
+	for _, level := range levels {
+		for _, glob := range append(append([]string{}, level.order.First...), level.order.Last...) {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("%s: invalid glob %q: %v", level.name, glob, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rank places name before the unpinned names (negative), among them (zero) or
+// after them (positive).
+func (o Order) rank(name string) int {
+	for i, glob := range o.First {
+		if matched, _ := path.Match(glob, name); matched {
+			return i - len(o.First)
+		}
+	}
+	for i, glob := range o.Last {
+		if matched, _ := path.Match(glob, name); matched {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Less reports whether a sorts before b.
+func (o Order) Less(a, b string) bool {
+	if ra, rb := o.rank(a), o.rank(b); ra != rb {
+		return ra < rb
+	}
+	return NaturalLess(a, b)
+}
+
+// Sort sorts names in place.
+func (o Order) Sort(names []string) {
+	sort.SliceStable(names, func(i, j int) bool { return o.Less(names[i], names[j]) })
+}
+
+// NaturalLess compares strings with their runs of digits compared as numbers,
+// so that "us-east-2" sorts before "us-east-10". Equal strings by that
+// measure, such as "01" and "1", fall back to a plain comparison.
+func NaturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numberA, numberB := trimZeros(a[startA:i]), trimZeros(b[startB:j])
+			if len(numberA) != len(numberB) {
+				return len(numberA) < len(numberB)
+			}
+			if numberA != numberB {
+				return numberA < numberB
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func trimZeros(number string) string {
+	for len(number) > 1 && number[0] == '0' {
+		number = number[1:]
+	}
+	return number
+}
+
+// less orders files by project, region and stack, then by path.
+func (o Ordering) less(a, b File) bool {
+	levels := []struct {
+		order Order
+		a, b  string
+	}{
+		{o.Projects, a.ProjectID, b.ProjectID},
+		{o.Regions, a.RegionID, b.RegionID},
+		{o.Stacks, a.StackID, b.StackID},
+	}
+	for _, level := range levels {
+		if level.a != level.b {
+			return level.order.Less(level.a, level.b)
+		}
+	}
+	return NaturalLess(a.Path, b.Path)
+}
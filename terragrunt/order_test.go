@@ -0,0 +1,125 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"us-east-2", "us-east-10", true},
+		{"us-east-10", "us-east-2", false},
+		{"app", "app-2", true},
+		{"node9", "node10", true},
+		{"v1.2.0", "v1.10.0", true},
+		{"01", "1", true},
+		{"1", "01", false},
+		{"a", "a", false},
+		{"Zeta", "alpha", true},
+	}
+	for _, test := range tests {
+		if got := NaturalLess(test.a, test.b); got != test.less {
+			t.Errorf("NaturalLess(%q, %q) = %v, want %v", test.a, test.b, got, test.less)
+		}
+	}
+}
+
+func TestOrderSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		names []string
+		want  []string
+	}{
+		{
+			name:  "natural",
+			names: []string{"us-east-10", "eu-west-1", "us-east-2", "us-east-1"},
+			want:  []string{"eu-west-1", "us-east-1", "us-east-2", "us-east-10"},
+		},
+		{
+			name:  "prod last",
+			order: Order{Last: []string{"prod"}},
+			names: []string{"prod", "staging", "dev"},
+			want:  []string{"dev", "staging", "prod"},
+		},
+		{
+			name:  "pins keep the order of their globs",
+			order: Order{First: []string{"shared", "dev*"}, Last: []string{"staging", "prod*"}},
+			names: []string{"prod-eu", "dev-2", "qa", "staging", "prod-us", "shared", "dev-10", "sandbox"},
+			want:  []string{"shared", "dev-2", "dev-10", "qa", "sandbox", "staging", "prod-eu", "prod-us"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := append([]string{}, test.names...)
+			test.order.Sort(names)
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("got %v, want %v", names, test.want)
+			}
+		})
+	}
+}
+
+func TestOrderingValidate(t *testing.T) {
+	if err := (Ordering{Projects: Order{Last: []string{"prod-*"}}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Ordering{Regions: Order{First: []string{"us-["}}}).Validate(); err == nil {
+		t.Error("expected an error for an invalid glob")
+	}
+}
+
+func TestWorkspaceOrdering(t *testing.T) {
+	workspace := Workspace{
+		Ordering: Ordering{Projects: Order{Last: []string{"prod"}}},
+		Projects: make(map[string]*Project),
+	}
+	for _, place := range [][3]string{
+		{"prod", "us-east-1", "vpc"},
+		{"prod", "us-east-10", "vpc"},
+		{"prod", "us-east-2", "app"},
+		{"dev", "us-east-1", "vpc"},
+		{"dev", "us-east-1", "app"},
+		{"staging", "eu-west-1", "db"},
+	} {
+		_, _, stack := workspace.fetchOrCreateHierarchy(place[0], place[1], place[2])
+		stack.Files = append(stack.Files, File{
+			Path:      place[0] + "/" + place[1] + "/" + place[2] + "/terragrunt.hcl",
+			ProjectID: place[0],
+			RegionID:  place[1],
+			StackID:   place[2],
+		})
+	}
+
+	wantFiles := []string{
+		"dev/us-east-1/app/terragrunt.hcl",
+		"dev/us-east-1/vpc/terragrunt.hcl",
+		"staging/eu-west-1/db/terragrunt.hcl",
+		"prod/us-east-1/vpc/terragrunt.hcl",
+		"prod/us-east-2/app/terragrunt.hcl",
+		"prod/us-east-10/vpc/terragrunt.hcl",
+	}
+	// Maps are iterated in a different order every time, so the order must
+	// hold over many calls.
+	for i := 0; i < 20; i++ {
+		if got, want := workspace.GetProjects(), []string{"dev", "staging", "prod"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetProjects() = %v, want %v", got, want)
+		}
+		if got, want := workspace.GetRegions(), []string{"eu-west-1", "us-east-1", "us-east-2", "us-east-10"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetRegions() = %v, want %v", got, want)
+		}
+		if got, want := workspace.GetStacks(), []string{"app", "db", "vpc"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetStacks() = %v, want %v", got, want)
+		}
+		var paths []string
+		for _, file := range workspace.Files() {
+			paths = append(paths, file.Path)
+		}
+		if !reflect.DeepEqual(paths, wantFiles) {
+			t.Fatalf("Files() = %v, want %v", paths, wantFiles)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package terragrunt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PlanChanges is the parsed output of `terraform show -json` for a plan file.
+type PlanChanges struct {
+	Create    int
+	Update    int
+	Replace   int
+	Delete    int
+	Resources []ResourceChange
+}
+
+// Action is what a plan does to a resource.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionReplace Action = "replace"
+	ActionDelete  Action = "delete"
+	ActionRead    Action = "read"
+	ActionNoop    Action = "no-op"
+)
+
+type ResourceChange struct {
+	Address    string
+	Action     Action
+	Attributes []AttributeChange
+}
+
+// AttributeChange is a top-level attribute whose value changes. Values are
+// rendered as JSON, or as a placeholder when they are unknown or sensitive.
+type AttributeChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+const (
+	unknownValue   = "(known after apply)"
+	sensitiveValue = "(sensitive)"
+)
+
+// Badge summarizes the plan as "+create ~update -delete", adding "±replace"
+// when resources are replaced.
+func (p *PlanChanges) Badge() string {
+	badge := fmt.Sprintf("+%d ~%d -%d", p.Create, p.Update, p.Delete)
+	if p.Replace > 0 {
+		badge += fmt.Sprintf(" ±%d", p.Replace)
+	}
+	return badge
+}
+
+// HasChanges reports whether applying the plan would change any resource.
+func (p *PlanChanges) HasChanges() bool {
+	return p.Create+p.Update+p.Replace+p.Delete > 0
+}
+
+// RunPlan runs a plan that is saved to a temporary plan file and then parsed
+// with `terragrunt show -json`. The output of the plan itself is streamed to
+// onOutput like RunCommand does. With -detailed-exitcode, the exit code that
+// signals pending changes is not treated as an error.
+func RunPlan(ctx context.Context, stackPath string, command Command, onOutput OutputFunc) (Result, *PlanChanges, error) {
+	dir, err := os.MkdirTemp("", "terragrunt-vision-plan")
+	if err != nil {
+		return Result{ExitCode: -1}, nil, err
+	}
+	defer os.RemoveAll(dir)
+	planFile := filepath.Join(dir, "tfplan")
+
+	command.Args = append(append([]string{}, command.Args...), "-out="+planFile)
+	result, err := RunCommand(ctx, stackPath, command, onOutput)
+	if err != nil && !hasChangesExit(command, err) {
+		return result, nil, err
+	}
+
+	show := Command{
+		Name:       "show",
+		Args:       []string{"-json", planFile},
+		Env:        command.Env,
+		AWSProfile: command.AWSProfile,
+		AWSRegion:  command.AWSRegion,
+	}
+	data, err := captureCommand(ctx, stackPath, show)
+	if err != nil {
+		return result, nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	plan, err := ParsePlan(data)
+	if err != nil {
+		return result, nil, err
+	}
+	return result, plan, nil
+}
+
+// hasChangesExit reports whether err is the exit code 2 that plan uses with
+// -detailed-exitcode to signal that there are changes.
+func hasChangesExit(command Command, err error) bool {
+	if ExitCode(err) != 2 {
+		return false
+	}
+	for _, arg := range command.Args {
+		if arg == "-detailed-exitcode" || arg == "--detailed-exitcode" {
+			return true
+		}
+	}
+	return false
+}
+
+type planJSON struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Mode    string `json:"mode"`
+		Change  struct {
+			Actions         []string        `json:"actions"`
+			Before          json.RawMessage `json:"before"`
+			After           json.RawMessage `json:"after"`
+			AfterUnknown    json.RawMessage `json:"after_unknown"`
+			BeforeSensitive json.RawMessage `json:"before_sensitive"`
+			AfterSensitive  json.RawMessage `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// ParsePlan parses the JSON representation of a plan. Anything printed before
+// the JSON document, such as terragrunt log lines, is ignored.
+func ParsePlan(data []byte) (*PlanChanges, error) {
+	if start := bytes.IndexByte(data, '{'); start > 0 {
+		data = data[start:]
+	}
+	var raw planJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %v", err)
+	}
+
+	plan := &PlanChanges{}
+	for _, rc := range raw.ResourceChanges {
+		action := planAction(rc.Change.Actions)
+		switch action {
+		case ActionCreate:
+			plan.Create++
+		case ActionUpdate:
+			plan.Update++
+		case ActionReplace:
+			plan.Replace++
+		case ActionDelete:
+			plan.Delete++
+		default:
+			continue
+		}
+		plan.Resources = append(plan.Resources, ResourceChange{
+			Address: rc.Address,
+			Action:  action,
+			Attributes: attributeChanges(
+				objectOf(rc.Change.Before), objectOf(rc.Change.After), objectOf(rc.Change.AfterUnknown),
+				objectOf(rc.Change.BeforeSensitive), objectOf(rc.Change.AfterSensitive),
+			),
+		})
+	}
+	return plan, nil
+}
+
+func planAction(actions []string) Action {
+	switch strings.Join(actions, ",") {
+	case "create":
+		return ActionCreate
+	case "update":
+		return ActionUpdate
+	case "delete":
+		return ActionDelete
+	case "delete,create", "create,delete":
+		return ActionReplace
+	case "read":
+		return ActionRead
+	}
+	return ActionNoop
+}
+
+func objectOf(raw json.RawMessage) map[string]json.RawMessage {
+	object := make(map[string]json.RawMessage)
+	json.Unmarshal(raw, &object)
+	return object
+}
+
+func attributeChanges(before, after, unknown, beforeSensitive, afterSensitive map[string]json.RawMessage) []AttributeChange {
+	names := make(map[string]bool)
+	for _, object := range []map[string]json.RawMessage{before, after, unknown} {
+		for name := range object {
+			names[name] = true
+		}
+	}
+
+	var changes []AttributeChange
+	for name := range names {
+		b := renderValue(before[name], beforeSensitive[name])
+		a := renderValue(after[name], afterSensitive[name])
+		if isTrue(unknown[name]) {
+			a = unknownValue
+		}
+		if a != b {
+			changes = append(changes, AttributeChange{Name: name, Before: b, After: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func renderValue(value, sensitive json.RawMessage) string {
+	if isTrue(sensitive) {
+		return sensitiveValue
+	}
+	if len(value) == 0 {
+		return "null"
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, value); err != nil {
+		return string(value)
+	}
+	return compact.String()
+}
+
+func isTrue(raw json.RawMessage) bool {
+	return string(raw) == "true"
+}
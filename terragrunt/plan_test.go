@@ -0,0 +1,77 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions string
+		counts  [4]int
+		action  Action
+	}{
+		{"create", `["create"]`, [4]int{1, 0, 0, 0}, ActionCreate},
+		{"update", `["update"]`, [4]int{0, 1, 0, 0}, ActionUpdate},
+		{"replace", `["delete", "create"]`, [4]int{0, 0, 1, 0}, ActionReplace},
+		{"create before destroy", `["create", "delete"]`, [4]int{0, 0, 1, 0}, ActionReplace},
+		{"delete", `["delete"]`, [4]int{0, 0, 0, 1}, ActionDelete},
+		{"no-op", `["no-op"]`, [4]int{}, ""},
+		{"read", `["read"]`, [4]int{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan, err := ParsePlan([]byte(`{"resource_changes": [{"address": "aws_s3_bucket.logs", "change": {"actions": ` + test.actions + `}}]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if counts := [4]int{plan.Create, plan.Update, plan.Replace, plan.Delete}; counts != test.counts {
+				t.Errorf("counts = %v, want %v", counts, test.counts)
+			}
+			if test.action == "" {
+				if len(plan.Resources) != 0 {
+					t.Errorf("resources = %v, want none", plan.Resources)
+				}
+				return
+			}
+			if len(plan.Resources) != 1 || plan.Resources[0].Action != test.action {
+				t.Errorf("resources = %v, want one %s", plan.Resources, test.action)
+			}
+		})
+	}
+}
+
+func TestParsePlanAttributes(t *testing.T) {
+	plan, err := ParsePlan([]byte(`time=... level=info msg=Downloading
+{"resource_changes": [{
+  "address": "aws_instance.app",
+  "change": {
+    "actions": ["update"],
+    "before": {"instance_type": "t3.small", "ami": "ami-1", "password": "a", "tags": {"Team": "core"}},
+    "after": {"instance_type": "t3.large", "ami": "ami-1", "password": "b", "tags": {"Team": "core"}},
+    "after_unknown": {"arn": true},
+    "before_sensitive": {"password": true},
+    "after_sensitive": {"password": true}
+  }
+}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AttributeChange{
+		{Name: "arn", Before: "null", After: unknownValue},
+		{Name: "instance_type", Before: `"t3.small"`, After: `"t3.large"`},
+	}
+	if got := plan.Resources[0].Attributes; !reflect.DeepEqual(got, want) {
+		t.Errorf("attributes = %+v, want %+v", got, want)
+	}
+	if badge := plan.Badge(); badge != "+0 ~1 -0" {
+		t.Errorf("badge = %q", badge)
+	}
+}
+
+func TestParsePlanInvalid(t *testing.T) {
+	if _, err := ParsePlan([]byte("Error: no plan")); err == nil {
+		t.Error("got no error")
+	}
+}
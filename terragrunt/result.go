@@ -0,0 +1,61 @@
+package terragrunt
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// stderrTailLines is how much of stderr is kept in a Result.
+const stderrTailLines = 20
+
+// Result describes how a terragrunt process ended.
+type Result struct {
+	// Output is everything written to stdout and stderr.
+	Output string
+	// ExitCode is -1 when terragrunt could not be started or was killed.
+	ExitCode int
+	Duration time.Duration
+	// StderrTail holds the last lines written to stderr.
+	StderrTail string
+	// Class is the known cause of a failure, if any.
+	Class ErrorClass
+}
+
+// ErrorClass is a known cause of failure that usually needs the user to act
+// before running the command again.
+type ErrorClass string
+
+const (
+	StateLock        ErrorClass = "state lock"
+	AuthExpired      ErrorClass = "auth expired"
+	ProviderDownload ErrorClass = "provider download failed"
+)
+
+var errorPatterns = []struct {
+	class   ErrorClass
+	pattern *regexp.Regexp
+}{
+	{StateLock, regexp.MustCompile(`(?i)error acquiring the state lock|state lock|ConditionalCheckFailedException`)},
+	{AuthExpired, regexp.MustCompile(`(?i)ExpiredToken|token (has|is) expired|security token included in the request is (expired|invalid)|InvalidClientTokenId|sso session .*expired|no valid credential sources|NoCredentialProviders`)},
+	{ProviderDownload, regexp.MustCompile(`(?i)failed to install provider|failed to query available provider packages|could not retrieve the list of available versions|error while installing .*provider|failed to download module`)},
+}
+
+// classifyError returns the class of the first known error found in stderr.
+func classifyError(stderr string) ErrorClass {
+	for _, p := range errorPatterns {
+		if p.pattern.MatchString(stderr) {
+			return p.class
+		}
+	}
+	return ""
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
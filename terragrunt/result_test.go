@@ -0,0 +1,48 @@
+package terragrunt
+
+import "testing"
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   ErrorClass
+	}{
+		{"state lock", "Error: Error acquiring the state lock", StateLock},
+		{"dynamodb lock", "ConditionalCheckFailedException: The conditional request failed", StateLock},
+		{"expired token", "ExpiredToken: The security token included in the request is expired", AuthExpired},
+		{"invalid token", "InvalidClientTokenId: The security token included in the request is invalid.", AuthExpired},
+		{"expired sso session", "Error: the SSO session has expired or is invalid", AuthExpired},
+		{"no credentials", "Error: No valid credential sources found", AuthExpired},
+		{"provider install", "Error: Failed to install provider hashicorp/aws", ProviderDownload},
+		{"provider query", "Failed to query available provider packages", ProviderDownload},
+		{"module download", "Error: Failed to download module", ProviderDownload},
+		{"first known error", "Error: Failed to install provider\nError acquiring the state lock", StateLock},
+		{"unknown", "Error: Unsupported argument", ""},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := classifyError(test.stderr); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 2, "b\nc"},
+		{"a\nb\n", 5, "a\nb"},
+		{"", 2, ""},
+	}
+	for _, test := range tests {
+		if got := tail(test.s, test.n); got != test.want {
+			t.Errorf("tail(%q, %d) = %q, want %q", test.s, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package terragrunt
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Query searches the files of a workspace. It is parsed from whitespace
+// separated terms, all of which must match:
+//
+//	project:prod region:us-* input.instance_type=t3.large source~vpc encrypt
+//
+// A term is a field followed by an operator and a value: `:` matches a glob,
+// `=` an exact value and `~` a case insensitive substring. Fields are the
+// ones of Filter, source for the effective terraform source and input.<path>
+// for an effective input, with paths as returned by Flatten. A term without
+// a field, or in double quotes, is searched in the content of the file.
+type Query struct {
+	terms []queryTerm
+}
+
+type queryTerm struct {
+	field string
+	op    byte
+	value string
+}
+
+var queryField = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.\[\]-]*)([:=~])(.*)$`)
+
+// ParseQuery parses a search query. An empty query matches every file.
+func ParseQuery(query string) (Query, error) {
+	tokens, err := splitQuery(query)
+	if err != nil {
+		return Query{}, err
+	}
+	var q Query
+	for _, token := range tokens {
+		if token.quoted {
+			q.terms = append(q.terms, queryTerm{value: token.text})
+			continue
+		}
+		match := queryField.FindStringSubmatch(token.text)
+		if match == nil {
+			q.terms = append(q.terms, queryTerm{value: token.text})
+			continue
+		}
+		term := queryTerm{field: match[1], op: match[2][0], value: strings.Trim(match[3], `"`)}
+		if term.value == "" {
+			return Query{}, fmt.Errorf("search term %q has no value", token.text)
+		}
+		if term.op == ':' {
+			if _, err := path.Match(term.value, ""); err != nil {
+				return Query{}, fmt.Errorf("invalid glob in search term %q: %v", token.text, err)
+			}
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery splits query on whitespace outside of double quotes.
+func splitQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	var current strings.Builder
+	quoted, inQuotes, started := false, false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if !started {
+				quoted = true
+			}
+			inQuotes, started = !inQuotes, true
+			current.WriteRune(r)
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n'):
+			if started {
+				tokens = append(tokens, newQueryToken(current.String(), quoted))
+				current.Reset()
+				quoted, started = false, false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in %q", query)
+	}
+	if started {
+		tokens = append(tokens, newQueryToken(current.String(), quoted))
+	}
+	return tokens, nil
+}
+
+func newQueryToken(text string, quoted bool) queryToken {
+	if quoted {
+		text = strings.Trim(text, `"`)
+	}
+	return queryToken{text: text, quoted: quoted}
+}
+
+// Empty reports whether the query has no terms.
+func (q Query) Empty() bool {
+	return len(q.terms) == 0
+}
+
+// LineMatch is a line of a file that holds a searched value.
+type LineMatch struct {
+	// Line is the line number, starting at 1.
+	Line int
+	Text string
+	// Spans are the byte offsets of the matches in Text.
+	Spans [][2]int
+}
+
+// Match reports whether file matches every term of the query and returns the
+// lines of its content to highlight.
+func (q Query) Match(file File) ([]LineMatch, bool) {
+	var highlights []string
+	for _, term := range q.terms {
+		if !term.match(file) {
+			return nil, false
+		}
+		if term.op != ':' {
+			highlights = append(highlights, term.value)
+		}
+	}
+	return findLines(file.Content(), highlights), true
+}
+
+func (t queryTerm) match(file File) bool {
+	if t.field == "" {
+		return strings.Contains(strings.ToLower(file.Content()), strings.ToLower(t.value))
+	}
+
+	var values []string
+	switch {
+	case t.field == "source":
+		values = []string{file.Source()}
+	case strings.HasPrefix(t.field, "input."):
+		key := strings.TrimPrefix(t.field, "input.")
+		for leaf, value := range Flatten(file.Inputs()) {
+			if leaf == key || strings.HasPrefix(leaf, key+".") || strings.HasPrefix(leaf, key+"[") {
+				values = append(values, FormatValue(value))
+			}
+		}
+	default:
+		if value, ok := filterValue(file, t.field); ok {
+			values = []string{value}
+		}
+	}
+
+	for _, value := range values {
+		switch t.op {
+		case ':':
+			if matchAny([]string{t.value}, value) {
+				return true
+			}
+		case '=':
+			if value == t.value {
+				return true
+			}
+		case '~':
+			if strings.Contains(strings.ToLower(value), strings.ToLower(t.value)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findLines returns the lines of content holding any of values, ignoring
+// case. Matching the lines themselves keeps the spans on rune boundaries,
+// even for runes whose lower case is of a different length.
+func findLines(content string, values []string) []LineMatch {
+	if len(values) == 0 {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, 0, len(values))
+	for _, value := range values {
+		patterns = append(patterns, regexp.MustCompile("(?i)"+regexp.QuoteMeta(value)))
+	}
+	var matches []LineMatch
+	for i, line := range strings.Split(content, "\n") {
+		var spans [][2]int
+		for _, pattern := range patterns {
+			for _, span := range pattern.FindAllStringIndex(line, -1) {
+				spans = append(spans, [2]int{span[0], span[1]})
+			}
+		}
+		if len(spans) > 0 {
+			matches = append(matches, LineMatch{Line: i + 1, Text: line, Spans: mergeSpans(spans)})
+		}
+	}
+	return matches
+}
+
+// mergeSpans sorts spans and joins the ones that overlap.
+func mergeSpans(spans [][2]int) [][2]int {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span[0] <= last[1] {
+			if span[1] > last[1] {
+				last[1] = span[1]
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// SearchResult is a file matching a query.
+type SearchResult struct {
+	File    File
+	Matches []LineMatch
+}
+
+// Search returns the files of the workspace matching query, in the order of
+// Files.
+func (h *Workspace) Search(query Query) []SearchResult {
+	var results []SearchResult
+	for _, file := range h.Files() {
+		if matches, ok := query.Match(file); ok {
+			results = append(results, SearchResult{File: file, Matches: matches})
+		}
+	}
+	return results
+}
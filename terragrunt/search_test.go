@@ -0,0 +1,119 @@
+package terragrunt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSearchContent = `terraform {
+  source = "git::https://example.com/mods.git//vpc?ref=v1.0.0"
+}
+inputs = {
+  instance_type = "t3.large"
+  encrypt       = true
+  tags          = { Team = "Core" }
+}
+`
+
+// testSearchFile writes content to a file of the prod/us-east-1/vpc stack.
+func testSearchFile(t *testing.T, content string) File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "terragrunt.hcl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := ParseConfig(path, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return File{Path: path, ProjectID: "prod", RegionID: "us-east-1", StackID: "vpc", Config: config, content: &lazyContent{}}
+}
+
+func TestQueryMatch(t *testing.T) {
+	file := testSearchFile(t, testSearchContent)
+	tests := []struct {
+		query string
+		match bool
+	}{
+		{"", true},
+		{"project:prod", true},
+		{"project:pr*", true},
+		{"project:staging", false},
+		{"region=us-east-1", true},
+		{"region=us-east", false},
+		{"region~EAST", true},
+		{"stack~db", false},
+		{"source~VPC", true},
+		{"source=vpc", false},
+		{"input.instance_type=t3.large", true},
+		{"input.instance_type:t3.*", true},
+		{"input.instance_type=t3.small", false},
+		{"input.tags~core", true},
+		{"input.tags.Team=Core", true},
+		{"input.missing~x", false},
+		{"encrypt", true},
+		{"ENCRYPT project:prod", true},
+		{`"instance_type ="`, true},
+		{"kms_key", false},
+	}
+	for _, test := range tests {
+		query, err := ParseQuery(test.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", test.query, err)
+			continue
+		}
+		if _, got := query.Match(file); got != test.match {
+			t.Errorf("ParseQuery(%q).Match = %v, want %v", test.query, got, test.match)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{`"unterminated`, "project:", "region:[", "input.x="} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q): got no error", query)
+		}
+	}
+}
+
+func TestFindLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		values  []string
+		want    []LineMatch
+	}{
+		{
+			name:    "case insensitive",
+			content: "name = \"App\"\napp_id = 1",
+			values:  []string{"app"},
+			want: []LineMatch{
+				{Line: 1, Text: `name = "App"`, Spans: [][2]int{{8, 11}}},
+				{Line: 2, Text: "app_id = 1", Spans: [][2]int{{0, 3}}},
+			},
+		},
+		{
+			name:    "overlapping values",
+			content: "vpc_cidr",
+			values:  []string{"vpc_c", "cidr"},
+			want:    []LineMatch{{Line: 1, Text: "vpc_cidr", Spans: [][2]int{{0, 8}}}},
+		},
+		{
+			// İ lowers to two runes, which would shift byte offsets taken
+			// from the lower case line.
+			name:    "runes changing length",
+			content: `city = "İstanbul"`,
+			values:  []string{"stanbul"},
+			want:    []LineMatch{{Line: 1, Text: `city = "İstanbul"`, Spans: [][2]int{{10, 17}}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := findLines(test.content, test.values); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,26 +1,65 @@
 package terragrunt
 
 import (
-	"errors"
-	"fmt"
-	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
-
-	"github.com/caiovfernandes/terragrunt-runner/utils"
+	"sort"
+	"sync"
 )
 
 type File struct {
 	Path      string
-	Content   string
 	RegionID  string
 	ProjectID string
 	StackID   string
+	// Segments holds the directory names captured by the named segments of
+	// the workspace layout.
+	Segments map[string]string
+	// Config is the parsed content, nil when the file could not be parsed.
+	Config *Config
+	// Effective is Config merged with the files it includes, nil when they
+	// could not be resolved.
+	Effective *EffectiveConfig
+	// Dependencies are the directories of the units this file depends on,
+	// taken from its `dependency` and `dependencies` blocks.
+	Dependencies []string
+
+	content *lazyContent
+}
+
+// lazyContent is read the first time it is needed, and then shared by every
+// copy of the file.
+type lazyContent struct {
+	once sync.Once
+	text string
+}
+
+// set stores text as the content, unless it was already read.
+func (c *lazyContent) set(text string) {
+	c.once.Do(func() { c.text = text })
+}
+
+// Content returns the content of the file, read on first use. It is empty
+// when the file cannot be read.
+func (f File) Content() string {
+	if f.content == nil {
+		return ""
+	}
+	f.content.once.Do(func() {
+		data, err := os.ReadFile(f.Path)
+		if err == nil {
+			f.content.text = string(data)
+		}
+	})
+	return f.content.text
 }
 
 type Workspace struct {
+	Root   string
+	Layout Layout
+	// Ordering sorts the projects, regions and stacks returned by the
+	// accessors of the workspace.
+	Ordering Ordering
 	Projects map[string]*Project
 }
 
@@ -39,42 +78,6 @@ type Stack struct {
 	Files []File
 }
 
-const (
-	baseFolder         = "workspaces"
-	minPathPartsLength = 4
-)
-
-func (h *Workspace) addFileToHierarchy(filePath string) {
-	pathParts, baseIndex := extractPathParts(filePath, baseFolder)
-	if baseIndex == -1 || len(pathParts) < baseIndex+minPathPartsLength {
-		fmt.Printf("Skipping malformed path: %s\n", filePath)
-		return
-	}
-
-	projectName, regionName, stackName := pathParts[baseIndex+1], pathParts[baseIndex+2], pathParts[baseIndex+3]
-	_, _, stack := h.fetchOrCreateHierarchy(projectName, regionName, stackName)
-
-	content, err := getFileContent(filePath)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	stack.Files = append(stack.Files, File{Path: filePath, Content: content, RegionID: regionName, ProjectID: projectName, StackID: stackName})
-}
-
-func extractPathParts(filePath, baseFolder string) ([]string, int) {
-	pathParts := strings.Split(filePath, "/")
-	baseIndex := -1
-	for i, part := range pathParts {
-		if part == baseFolder {
-			baseIndex = i
-			break
-		}
-	}
-	return pathParts, baseIndex
-}
-
 func (h *Workspace) fetchOrCreateHierarchy(projectName, regionName, stackName string) (*Project, *Region, *Stack) {
 	project := h.getOrCreateProject(projectName)
 	region := project.getOrCreateRegion(regionName)
@@ -85,7 +88,7 @@ func (h *Workspace) fetchOrCreateHierarchy(projectName, regionName, stackName st
 func (h *Workspace) getOrCreateProject(name string) *Project {
 	project, exists := h.Projects[name]
 	if !exists {
-		project = &Project{Regions: make(map[string]*Region)}
+		project = &Project{Name: name, Regions: make(map[string]*Region)}
 		h.Projects[name] = project
 	}
 	return project
@@ -94,7 +97,7 @@ func (h *Workspace) getOrCreateProject(name string) *Project {
 func (p *Project) getOrCreateRegion(name string) *Region {
 	region, exists := p.Regions[name]
 	if !exists {
-		region = &Region{Stacks: make(map[string]*Stack)}
+		region = &Region{Name: name, Stacks: make(map[string]*Stack)}
 		p.Regions[name] = region
 	}
 	return region
@@ -103,107 +106,50 @@ func (p *Project) getOrCreateRegion(name string) *Region {
 func (r *Region) getOrCreateStack(name string) *Stack {
 	stack, exists := r.Stacks[name]
 	if !exists {
-		stack = &Stack{}
+		stack = &Stack{Name: name}
 		r.Stacks[name] = stack
 	}
 	return stack
 }
 
-func (h *Workspace) PrintHierarchy() {
-	for projectName, project := range h.Projects {
-		fmt.Printf("Project: %s\n", projectName)
-		for regionName, region := range project.Regions {
-			fmt.Printf("  Region: %s\n", regionName)
-			for stackName, stack := range region.Stacks {
-				fmt.Printf("    Stack: %s\n", stackName)
-				for _, file := range stack.Files {
-					fmt.Printf("      File: %s\n", file.Path)
-				}
+// Files returns every terragrunt file of the workspace, sorted by project,
+// region and stack according to the ordering of the workspace.
+func (h *Workspace) Files() []File {
+	var files []File
+	for _, project := range h.Projects {
+		for _, region := range project.Regions {
+			for _, stack := range region.Stacks {
+				files = append(files, stack.Files...)
 			}
 		}
 	}
+	sort.SliceStable(files, func(i, j int) bool { return h.Ordering.less(files[i], files[j]) })
+	return files
 }
 
-func GetWorkspace() (Workspace, error) {
-	root := Workspace{Projects: make(map[string]*Project)}
-	if len(os.Args) < 2 {
-		return Workspace{}, errors.New("Usage: program <root-directory>")
-	}
-	rootDir := os.Args[1]
-	fmt.Printf("rootDir: %s\n", rootDir)
-	terragruntFiles, err := getTerragruntFiles(rootDir)
-	if err != nil {
-		return Workspace{}, err
-	}
-	for _, file := range terragruntFiles {
-		root.addFileToHierarchy(file)
-	}
-	return root, nil
-}
-
-func getTerragruntFiles(rootDir string) ([]string, error) {
-	var files []string
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// FindFile returns the file at path, which may also be the directory of the
+// file.
+func (h *Workspace) FindFile(path string) (File, bool) {
+	path = filepath.Clean(path)
+	for _, file := range h.Files() {
+		if filepath.Clean(file.Path) == path || filepath.Dir(file.Path) == path {
+			return file, true
 		}
-		if info.IsDir() && strings.HasPrefix(info.Name(), ".") {
-			return filepath.SkipDir
-		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), "terragrunt.hcl") {
-			files = append(files, path)
-		}
-		return nil
-	})
-	return files, err
-}
-
-func getFileContent(filePath string) (string, error) {
-	content, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		fmt.Println(err)
-		return "", err
-	}
-	return string(content), nil
-}
-
-func RunTerraformInit(rootDir string) (string, error) {
-	// Remove the last item from the path
-	parentDir := filepath.Dir(rootDir)
-	accessKeyID, secretAccessKey, sessionToken, err := utils.GetAwsCredentials()
-	if err != nil {
-		return "", err
 	}
-
-	cmd := exec.Command("terragrunt", "init", "--terragrunt-forward-tf-stdout", "--no-color")
-	cmd.Env = append(os.Environ(),
-		"AWS_ACCESS_KEY_ID="+accessKeyID,
-		"AWS_SECRET_ACCESS_KEY="+secretAccessKey,
-		"AWS_SESSION_TOKEN="+sessionToken,
-	)
-	cmd.Dir = parentDir
-
-	outputBytes, err := cmd.CombinedOutput()
-	outputFile := filepath.Join(cmd.Dir, "output")
-	if err := ioutil.WriteFile(outputFile, outputBytes, 0644); err != nil {
-		return string(outputBytes), fmt.Errorf("failed to save output to file: %v", err)
-	}
-	return string(outputBytes), nil
+	return File{}, false
 }
 
+// GetProjects returns the names of the projects, in order.
 func (h *Workspace) GetProjects() []string {
-	projectMap := make(map[string]struct{})
+	projects := make([]string, 0, len(h.Projects))
 	for project := range h.Projects {
-		projectMap[project] = struct{}{}
-	}
-
-	projects := make([]string, 0, len(projectMap))
-	for project := range projectMap {
 		projects = append(projects, project)
 	}
+	h.Ordering.Projects.Sort(projects)
 	return projects
 }
 
+// GetRegions returns the names of the regions of every project, in order.
 func (h *Workspace) GetRegions() []string {
 	regionMap := make(map[string]struct{})
 	for _, project := range h.Projects {
@@ -216,9 +162,11 @@ func (h *Workspace) GetRegions() []string {
 	for region := range regionMap {
 		regions = append(regions, region)
 	}
+	h.Ordering.Regions.Sort(regions)
 	return regions
 }
 
+// GetStacks returns the names of the stacks of every region, in order.
 func (h *Workspace) GetStacks() []string {
 	stackMap := make(map[string]struct{})
 	for _, project := range h.Projects {
@@ -233,5 +181,6 @@ func (h *Workspace) GetStacks() []string {
 	for stack := range stackMap {
 		stacks = append(stacks, stack)
 	}
+	h.Ordering.Stacks.Sort(stacks)
 	return stacks
 }
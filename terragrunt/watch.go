@@ -0,0 +1,317 @@
+package terragrunt
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ChangeKind tells how a terragrunt file changed.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Modified
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Removed:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Change is a terragrunt file added, modified or removed while a workspace
+// is watched.
+type Change struct {
+	Kind ChangeKind
+	// File is the file as parsed after the change. Only its path is set when
+	// it was removed.
+	File        File
+	Diagnostics []Diagnostic
+}
+
+// watchDelay groups the events of a burst, such as an editor saving a file
+// or a branch checkout, into a single update.
+const watchDelay = 200 * time.Millisecond
+
+// Watcher reports the changes to the terragrunt files of a workspace until
+// its context is cancelled.
+type Watcher struct {
+	// Changes receives the changes of every burst of events, to be applied
+	// with Workspace.Apply. It is closed when the watcher stops.
+	Changes <-chan []Change
+	// Errors receives the errors of the underlying watcher, such as lost
+	// events. Errors are dropped while the previous one was not received.
+	Errors <-chan error
+
+	ctx       context.Context
+	workspace Workspace
+	loader    loader
+	notify    *fsnotify.Watcher
+	// files maps the known files to the absolute paths of the files they
+	// include.
+	files      map[string][]string
+	unresolved map[string]bool
+	changes    chan []Change
+	errors     chan error
+}
+
+// Watch watches the directories of workspace. opts restrict the directories
+// watched the way they restrict the ones searched by Load. Symbolic links
+// are not followed.
+func Watch(ctx context.Context, workspace Workspace, opts ...Option) (*Watcher, error) {
+	options := loadOptions{layout: workspace.Layout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan []Change)
+	errors := make(chan error, 1)
+	w := &Watcher{
+		Changes:    changes,
+		Errors:     errors,
+		ctx:        ctx,
+		workspace:  Workspace{Root: workspace.Root, Layout: workspace.Layout},
+		loader:     loader{ctx: ctx, root: workspace.Root, options: options},
+		notify:     notify,
+		files:      make(map[string][]string),
+		unresolved: make(map[string]bool),
+		changes:    changes,
+		errors:     errors,
+	}
+	for _, file := range workspace.Files() {
+		w.track(file)
+	}
+	if _, err := w.addDir(workspace.Root); err != nil {
+		notify.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+	defer w.notify.Close()
+
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[event.Name] = true
+			if timer == nil {
+				timer = time.After(watchDelay)
+			}
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			w.report(err)
+		case <-timer:
+			timer = nil
+			changes := w.update(pending)
+			pending = make(map[string]bool)
+			if len(changes) == 0 {
+				continue
+			}
+			select {
+			case w.changes <- changes:
+			case <-w.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) report(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// update parses again the files affected by the events on paths.
+func (w *Watcher) update(paths map[string]bool) []Change {
+	reparse := make(map[string]bool)
+	removed := make(map[string]bool)
+	for path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// Removed or renamed, possibly with a whole directory.
+			for file := range w.files {
+				if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+					removed[file] = true
+				}
+			}
+		case info.IsDir():
+			files, err := w.addDir(path)
+			if err != nil {
+				w.report(err)
+			}
+			for _, file := range files {
+				reparse[file] = true
+			}
+		case w.loader.isUnit(filepath.Base(path), w.loader.relative(path)):
+			reparse[path] = true
+		}
+
+		// Files follow the changes of the files they include. The ones
+		// whose includes are missing may resolve with any new file.
+		abs, _ := filepath.Abs(path)
+		for file, included := range w.files {
+			if w.unresolved[file] || contains(included, abs) {
+				reparse[file] = true
+			}
+		}
+	}
+
+	var changes []Change
+	for path := range removed {
+		w.untrack(path)
+		changes = append(changes, Change{Kind: Removed, File: File{Path: path}})
+	}
+	for path := range reparse {
+		if removed[path] {
+			continue
+		}
+		kind := Added
+		if _, ok := w.files[path]; ok {
+			kind = Modified
+		}
+		loaded := w.workspace.parseFile(path, nil)
+		if loaded.file == nil {
+			// No longer matches the layout.
+			if kind == Modified {
+				w.untrack(path)
+				changes = append(changes, Change{Kind: Removed, File: File{Path: path}})
+			}
+			continue
+		}
+		w.track(*loaded.file)
+		changes = append(changes, Change{Kind: kind, File: *loaded.file, Diagnostics: loaded.diagnostics})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].File.Path < changes[j].File.Path })
+	return changes
+}
+
+func (w *Watcher) track(file File) {
+	var included []string
+	if file.Effective != nil {
+		included = file.Effective.Files
+	}
+	w.files[file.Path] = included
+	if file.Config != nil && file.Effective == nil {
+		w.unresolved[file.Path] = true
+	} else {
+		delete(w.unresolved, file.Path)
+	}
+}
+
+func (w *Watcher) untrack(path string) {
+	delete(w.files, path)
+	delete(w.unresolved, path)
+}
+
+// addDir watches dir and the directories below it that are searched, and
+// returns the terragrunt files found in them.
+func (w *Watcher) addDir(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		rel := w.loader.relative(path)
+		if !entry.IsDir() {
+			if entry.Type().IsRegular() && w.loader.isUnit(entry.Name(), rel) {
+				files = append(files, path)
+			}
+			return nil
+		}
+		if path != w.workspace.Root && w.loader.skipDir(entry.Name(), rel) {
+			return filepath.SkipDir
+		}
+		return w.notify.Add(path)
+	})
+	return files, err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply updates the workspace with changes reported by a Watcher.
+func (h *Workspace) Apply(changes []Change) {
+	if h.Projects == nil {
+		h.Projects = make(map[string]*Project)
+	}
+	for _, change := range changes {
+		h.removeFile(change.File.Path)
+		if change.Kind != Removed {
+			h.addFile(change.File)
+		}
+	}
+}
+
+// removeFile removes the file at path, along with the stacks, regions and
+// projects left empty.
+func (h *Workspace) removeFile(path string) {
+	for projectName, project := range h.Projects {
+		for regionName, region := range project.Regions {
+			for stackName, stack := range region.Stacks {
+				for i, file := range stack.Files {
+					if file.Path == path {
+						stack.Files = append(stack.Files[:i:i], stack.Files[i+1:]...)
+						break
+					}
+				}
+				if len(stack.Files) == 0 {
+					delete(region.Stacks, stackName)
+				}
+			}
+			if len(region.Stacks) == 0 {
+				delete(project.Regions, regionName)
+			}
+		}
+		if len(project.Regions) == 0 {
+			delete(h.Projects, projectName)
+		}
+	}
+}
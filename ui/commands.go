@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+func (m *Model) updateCommands(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.confirming {
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
+			return m, m.quit()
+		case key.Matches(keyMsg, m.keys.Confirm):
+			return m, m.runSelectedCommand()
+		default:
+			m.confirming = false
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Back):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Run):
+		if m.commands[m.commandCursor].Destructive {
+			m.confirming = true
+			return m, nil
+		}
+		return m, m.runSelectedCommand()
+	case key.Matches(keyMsg, m.keys.Down):
+		m.commandCursor++
+		if m.commandCursor >= len(m.commands) {
+			m.commandCursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		m.commandCursor--
+		if m.commandCursor < 0 {
+			m.commandCursor = len(m.commands) - 1
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) runSelectedCommand() tea.Cmd {
+	m.focused = main
+	m.confirming = false
+
+	command := m.commands[m.commandCursor]
+	items := m.selectedItems()
+	return tea.Batch(m.clearSelection(), m.runCommand(items, command))
+}
+
+// targetName describes what the command picker will run against.
+func (m *Model) targetName() string {
+	items := m.selectedItems()
+	if len(items) == 1 {
+		return fmt.Sprintf("%s (%s)", items[0].title, items[0].description)
+	}
+	return fmt.Sprintf("%d stacks", len(items))
+}
+
+func (m *Model) commandsView() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("Run command on %s\n\n", m.targetName()))
+	for i, command := range m.commands {
+		if m.commandCursor == i {
+			s.WriteString("(•) ")
+		} else {
+			s.WriteString("( ) ")
+		}
+		s.WriteString(command.Title())
+		s.WriteString("\n")
+	}
+
+	if m.confirming {
+		command := m.commands[m.commandCursor]
+		s.WriteString("\n")
+		s.WriteString(warningStyle.Render(fmt.Sprintf("Run `%s` on %s? (y/N)", command, m.targetName())))
+		s.WriteString("\n")
+	} else {
+		s.WriteString("\n(press enter to run, esc to go back)\n")
+	}
+
+	return lipgloss.PlaceHorizontal(50, lipgloss.Center, s.String())
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"io"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	driftColor  = lipgloss.AdaptiveColor{Light: "#D75F00", Dark: "#FF875F"}
+	failedColor = lipgloss.AdaptiveColor{Light: "#D70000", Dark: "#FF5F5F"}
+)
+
+// itemDelegate renders the items of the main list, highlighting the stacks
+// whose last run failed or whose last drift plan found changes.
+type itemDelegate struct {
+	list.DefaultDelegate
+	drifted list.DefaultDelegate
+	failed  list.DefaultDelegate
+}
+
+func newItemDelegate() itemDelegate {
+	return itemDelegate{
+		DefaultDelegate: list.NewDefaultDelegate(),
+		drifted:         coloredDelegate(driftColor),
+		failed:          coloredDelegate(failedColor),
+	}
+}
+
+func coloredDelegate(color lipgloss.TerminalColor) list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(color)
+	d.Styles.NormalDesc = d.Styles.NormalDesc.Foreground(color)
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(color).BorderForeground(color)
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(color).BorderForeground(color)
+	return d
+}
+
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, _ := listItem.(Item)
+	switch {
+	case item.status == terragrunt.Failed:
+		d.failed.Render(w, m, index, listItem)
+	case item.drift == terragrunt.Drifted:
+		d.drifted.Render(w, m, index, listItem)
+	default:
+		d.DefaultDelegate.Render(w, m, index, listItem)
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+)
+
+// effectiveMarkdown renders the configuration of item once its includes are
+// merged, with the file every setting comes from.
+func (m *Model) effectiveMarkdown(item Item) string {
+	effective := item.file.Effective
+	if effective == nil {
+		return fmt.Sprintf("# `%s`\n\nThe includes of this file could not be resolved.", item.path)
+	}
+
+	s := strings.Builder{}
+	fmt.Fprintf(&s, "# Effective configuration of `%s`\n\nMerged from:\n\n", item.path)
+	for _, file := range effective.Files {
+		fmt.Fprintf(&s, "1. `%s`\n", m.relativePath(file))
+	}
+	s.WriteString("\n| Setting | Value | From |\n| --- | --- | --- |\n")
+	for _, setting := range effective.Settings() {
+		value := strings.ReplaceAll(terragrunt.FormatValue(setting.Value), "|", "\\|")
+		fmt.Fprintf(&s, "| `%s` | `%s` | %s |\n", setting.Name, value, m.relativePath(setting.Origin))
+	}
+	return s.String()
+}
+
+// relativePath shortens path to be relative to the workspace root when it is
+// inside of it.
+func (m *Model) relativePath(path string) string {
+	root, err := filepath.Abs(m.workspace.Root)
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -1,229 +1,289 @@
 package ui
 
-//type status int
-//
-//var (
-//	columnStyle  = lipgloss.NewStyle().Padding(1, 2)
-//	focusedStyle = lipgloss.NewStyle().Padding(1, 2).Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#FF00FF"))
-//
-//	models []tea.Model
-//)
-//
-//const (
-//	account status = iota
-//	region
-//	stack
-//)
-//
-//const (
-//	model status = iota
-//	form
-//)
-//
-//// Item
-//
-//type Item struct {
-//	status      status
-//	title       string
-//	description string
-//}
-//
-//func (t Item) FilterValue() string {
-//	return t.title
-//}
-//
-//func (t Item) Title() string {
-//	return t.title
-//}
-//
-//func (t Item) Description() string {
-//	return t.description
-//}
-//
-//func (t *Item) Next() {
-//	if t.status == stack {
-//		t.status = account
-//	} else {
-//		t.status++
-//	}
-//}
-//
-//func NewTask(status status, title, description string) Item {
-//	return Item{status: status, title: title, description: description}
-//}
-//
-//// Model
-//
-//type Model struct {
-//	focused status
-//	lists   []list.Model
-//	err     error
-//}
-//
-//func New() *Model {
-//	return &Model{}
-//}
-//
-//func (m *Model) MoveToNext() {
-//	selectedItem := m.lists[m.focused].SelectedItem()
-//	selectedTask := selectedItem.(Item)
-//	m.lists[selectedTask.status].RemoveItem(m.lists[m.focused].Index())
-//	selectedTask.Next()
-//	m.lists[selectedTask.status].InsertItem(len(m.lists[selectedTask.status].Items())-1, selectedTask)
-//}
-//
-//func (m *Model) initLists(width, height int) {
-//	defaultList := list.New(
-//		[]list.Item{},
-//		list.NewDefaultDelegate(),
-//		width,
-//		height-5,
-//	)
-//	defaultList.SetShowHelp(false)
-//	m.lists = []list.Model{defaultList, defaultList, defaultList}
-//
-//	// Account
-//	m.lists[account].Title = "To Do"
-//	m.lists[account].SetItems([]list.Item{
-//		Item{status: account, title: "Write documentation", description: "Write documentation for the project"},
-//		Item{status: account, title: "Write tests", description: "Write tests for the project"},
-//		Item{status: account, title: "Write code", description: "Write code for the project"},
-//	})
-//
-//	// Regions
-//	m.lists[region].Title = "In Progress"
-//	m.lists[region].SetItems([]list.Item{
-//		Item{status: region, title: "SoW", description: "Write statement of work."},
-//		Item{status: region, title: "Leverage Requirements", description: "Leverage project functional and non-functional requirements."},
-//		Item{status: region, title: "Architectural Documentation", description: "Write documentation about the solution architecture."},
-//	})
-//
-//	// Stacks
-//	m.lists[stack].Title = "Done"
-//	m.lists[stack].SetItems([]list.Item{
-//		Item{status: stack, title: "Project Idea", description: "That's the initial idea to creat this project......"},
-//	})
-//}
-//
-//func (m *Model) Init() tea.Cmd {
-//	m.focused = 0
-//	m.lists = make([]list.Model, 3)
-//	return nil
-//}
-//
-//func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-//	switch msg := msg.(type) {
-//	case tea.WindowSizeMsg:
-//		m.initLists(msg.Width, msg.Height/2)
-//	case tea.KeyMsg:
-//		switch msg.String() {
-//		case "h", "left":
-//			if m.focused > account {
-//				m.focused--
-//			}
-//		case "l", "right":
-//			if m.focused < stack {
-//				m.focused++
-//			}
-//		case "enter":
-//			m.MoveToNext()
-//		case "n":
-//			models[model] = m
-//			models[form].(*Form).focused = m.focused
-//			return models[form], nil
-//		case "ctrl+c", "q":
-//			return m, tea.Quit
-//		}
-//	case Item:
-//		task := msg
-//		return m, m.lists[task.status].InsertItem(len(m.lists[task.status].Items()), task)
-//	}
-//
-//	var cmd tea.Cmd
-//	m.lists[m.focused], cmd = m.lists[m.focused].Update(msg)
-//	return m, cmd
-//}
-//
-//func (m *Model) View() string {
-//	return lipgloss.JoinHorizontal(
-//		lipgloss.Left,
-//		getBoardStyle(m, account, m.lists[account]),
-//		getBoardStyle(m, region, m.lists[region]),
-//		getBoardStyle(m, stack, m.lists[stack]),
-//	)
-//}
-//
-//type Form struct {
-//	focused     status
-//	title       textinput.Model
-//	description textarea.Model
-//}
-//
-//func NewForm(focused status) *Form {
-//	form := &Form{focused: focused}
-//	form.title = textinput.New()
-//	form.title.Focus()
-//	form.description = textarea.New()
-//	return form
-//}
-//
-//func (f Form) CreateTaskFromForm(m *Model) tea.Msg {
-//	m.lists[f.focused].InsertItem(len(m.lists[f.focused].Items()), NewTask(f.focused, f.title.Value(), f.description.Value()))
-//	return NewTask(f.focused, f.title.Value(), f.description.Value())
-//}
-//
-//func (f Form) Init() tea.Cmd {
-//	return nil
-//}
-//
-//func (f Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-//	var cmd tea.Cmd
-//	switch msg := msg.(type) {
-//	case tea.KeyMsg:
-//		switch msg.String() {
-//		case "enter":
-//			if f.title.Focused() {
-//				f.title.Blur()
-//				f.description.Focus()
-//				return f, textarea.Blink
-//			} else {
-//				models[form] = f
-//				f.CreateTaskFromForm(models[model].(*Model))
-//				models[form] = NewForm(f.focused)
-//				return models[model], nil
-//			}
-//		}
-//	}
-//	if f.title.Focused() {
-//		f.title, cmd = f.title.Update(msg)
-//		return f, cmd
-//	} else {
-//		f.description, cmd = f.description.Update(msg)
-//		return f, cmd
-//	}
-//}
-//
-//func (f Form) View() string {
-//	return lipgloss.JoinVertical(lipgloss.Left, f.title.View(), f.description.View())
-//}
-//
-//// Functions
-//func getBoardStyle(m *Model, s status, listItem list.Model) string {
-//	if s == m.focused {
-//		return focusedStyle.Render(listItem.View())
-//	} else {
-//		return columnStyle.Render(listItem.View())
-//	}
-//}
-//
-//func main() {
-//	models = []tea.Model{New(), NewForm(account)}
-//	m := models[model]
-//	m.Init()
-//
-//	p := tea.NewProgram(m)
-//	if _, err := p.Run(); err != nil {
-//		fmt.Println("Error starting program:", err)
-//		panic(err)
-//	}
-//}
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/config"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Columns of the filter view.
+const (
+	projectColumn = iota
+	regionColumn
+	stackColumn
+	statusColumn
+	savedColumn
+	filterColumns
+)
+
+// notRun is the status of the items that did not run yet.
+const notRun = "not run"
+
+var filterColumnTitles = [filterColumns]string{"Project", "Region", "Stack", "Status", "Saved filters"}
+
+func statusName(status terragrunt.Status) string {
+	if status == 0 {
+		return notRun
+	}
+	return status.String()
+}
+
+func (m *Model) openFilter() {
+	m.draft = m.filter
+	m.filterMessage = ""
+	m.filterName = textinput.New()
+	m.filterName.Prompt = "name: "
+	m.focused = filter
+}
+
+// filterValues returns the values offered in column.
+func (m *Model) filterValues(column int) []string {
+	switch column {
+	case projectColumn:
+		return m.workspace.GetProjects()
+	case regionColumn:
+		return m.workspace.GetRegions()
+	case stackColumn:
+		return m.workspace.GetStacks()
+	case statusColumn:
+		values := []string{notRun}
+		for status := terragrunt.Queued; status <= terragrunt.Cancelled; status++ {
+			values = append(values, status.String())
+		}
+		return values
+	case savedColumn:
+		names := make([]string, len(m.config.Filters))
+		for i, saved := range m.config.Filters {
+			names[i] = saved.Name
+		}
+		return names
+	}
+	return nil
+}
+
+// draftValues returns the values of the draft selected in column.
+func (m *Model) draftValues(column int) *[]string {
+	switch column {
+	case projectColumn:
+		return &m.draft.Projects
+	case regionColumn:
+		return &m.draft.Regions
+	case stackColumn:
+		return &m.draft.Stacks
+	case statusColumn:
+		return &m.draft.Statuses
+	}
+	return nil
+}
+
+func (m *Model) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.filterName.Focused() {
+		return m.updateFilterName(keyMsg)
+	}
+
+	values := m.filterValues(m.filterColumn)
+	cursor := &m.filterCursors[m.filterColumn]
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Back, m.keys.Next):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Run):
+		m.filter = m.draft
+		m.focused = main
+		return m, m.refreshList()
+	case key.Matches(keyMsg, m.keys.Select):
+		if *cursor >= len(values) {
+			break
+		}
+		if m.filterColumn == savedColumn {
+			m.draft = m.config.Filters[*cursor]
+			break
+		}
+		selected := m.draftValues(m.filterColumn)
+		*selected = toggle(*selected, values[*cursor])
+		m.draft.Name = ""
+	case key.Matches(keyMsg, m.keys.ClearSelection):
+		m.draft = config.Filter{}
+	case key.Matches(keyMsg, m.keys.SaveFilter):
+		if m.draft.IsEmpty() {
+			m.filterMessage = "Select some values before saving the filter"
+			break
+		}
+		m.filterName.SetValue(m.draft.Name)
+		m.filterName.CursorEnd()
+		m.filterName.Focus()
+		return m, textinput.Blink
+	case key.Matches(keyMsg, m.keys.Left):
+		m.filterColumn = (m.filterColumn + filterColumns - 1) % filterColumns
+	case key.Matches(keyMsg, m.keys.Right):
+		m.filterColumn = (m.filterColumn + 1) % filterColumns
+	case key.Matches(keyMsg, m.keys.Down):
+		*cursor++
+		if *cursor >= len(values) {
+			*cursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		*cursor--
+		if *cursor < 0 {
+			*cursor = max(len(values)-1, 0)
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateFilterName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.filterName.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		name := strings.TrimSpace(m.filterName.Value())
+		if name == "" {
+			return m, nil
+		}
+		m.filterName.Blur()
+		m.draft.Name = name
+		if err := config.SaveFilter(m.draft); err != nil {
+			m.filterMessage = fmt.Sprintf("Failed to save the filter: %v", err)
+			return m, nil
+		}
+		m.config.AddFilter(m.draft)
+		m.filterMessage = fmt.Sprintf("Saved filter %q", name)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterName, cmd = m.filterName.Update(msg)
+	return m, cmd
+}
+
+func toggle(values []string, value string) []string {
+	for i, v := range values {
+		if v == value {
+			return append(values[:i:i], values[i+1:]...)
+		}
+	}
+	return append(values, value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFilter reports whether item matches every column of filter.
+func matchFilter(filter config.Filter, item Item) bool {
+	for _, column := range []struct {
+		values []string
+		value  string
+	}{
+		{filter.Projects, item.file.ProjectID},
+		{filter.Regions, item.file.RegionID},
+		{filter.Stacks, item.file.StackID},
+		{filter.Statuses, statusName(item.status)},
+	} {
+		if len(column.values) > 0 && !contains(column.values, column.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshList lists the items matching both the filter and the search.
+func (m *Model) refreshList() tea.Cmd {
+	var items []list.Item
+	for _, listItem := range m.fullList.Items() {
+		item := listItem.(Item)
+		if !matchFilter(m.filter, item) {
+			continue
+		}
+		if m.searchMatches != nil {
+			lines, ok := m.searchMatches[item.path]
+			if !ok {
+				continue
+			}
+			item.matches = lines
+		}
+		items = append(items, item)
+	}
+
+	title := listTitle
+	if m.query != "" {
+		title += fmt.Sprintf(" matching %q", m.query)
+	}
+	switch {
+	case m.filter.Name != "":
+		title += fmt.Sprintf(" (%s)", m.filter.Name)
+	case !m.filter.IsEmpty():
+		title += " (filtered)"
+	}
+	m.list.Title = title
+	m.list.ResetSelected()
+	cmd := m.list.SetItems(items)
+	m.listIndex = itemIndex(m.list)
+	m.buildTree()
+	return cmd
+}
+
+func (m *Model) filterView() string {
+	focused := columnStyle.Copy().Bold(true).Foreground(lipgloss.Color("62"))
+	var columns []string
+	for column := 0; column < filterColumns; column++ {
+		s := strings.Builder{}
+		title := filterColumnTitles[column]
+		if column == m.filterColumn {
+			s.WriteString(focused.Render(title))
+		} else {
+			s.WriteString(columnStyle.Render(title))
+		}
+		s.WriteString("\n\n")
+
+		selected := m.draftValues(column)
+		for i, value := range m.filterValues(column) {
+			cursor := "  "
+			if column == m.filterColumn && i == m.filterCursors[column] {
+				cursor = "> "
+			}
+			mark := "[ ] "
+			switch {
+			case column == savedColumn && value == m.draft.Name:
+				mark = "(•) "
+			case column == savedColumn:
+				mark = "( ) "
+			case contains(*selected, value):
+				mark = "[x] "
+			}
+			s.WriteString(cursor + mark + value + "\n")
+		}
+		columns = append(columns, columnStyle.Copy().MarginRight(4).Render(s.String()))
+	}
+
+	s := strings.Builder{}
+	s.WriteString(headerStyle.Render("Filter"))
+	s.WriteString("\n\n")
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	s.WriteString("\n")
+	if m.filterName.Focused() {
+		s.WriteString(m.filterName.View() + "\n")
+	}
+	if m.filterMessage != "" {
+		s.WriteString(m.filterMessage + "\n")
+	}
+	s.WriteString("\n(press space to toggle a value or load a saved filter, enter to apply, s to save, c to clear, esc to go back)\n")
+	return docStyle.Render(s.String())
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var headerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))
+
+func (m *Model) updateDependencies(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
+			return m, m.quit()
+		case key.Matches(keyMsg, m.keys.Back, m.keys.Graph):
+			m.focused = main
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) dependenciesView() string {
+	item, ok := m.list.SelectedItem().(Item)
+	if !ok {
+		return ""
+	}
+	unit := filepath.Dir(item.path)
+
+	s := strings.Builder{}
+	s.WriteString(headerStyle.Render(fmt.Sprintf("Dependencies of %s", unit)))
+	s.WriteString("\n\nUpstream (runs before this stack)\n")
+	s.WriteString(dependencyTree(unit, m.graph.Dependencies))
+	s.WriteString("\nDownstream (runs after this stack)\n")
+	s.WriteString(dependencyTree(unit, m.graph.Dependents))
+	s.WriteString("\n(press esc to go back)\n")
+	return docStyle.Render(s.String())
+}
+
+// dependencyTree renders the units reachable from unit through edges as an
+// ASCII tree. Units already shown on the current branch are marked as cycles
+// instead of being expanded again.
+func dependencyTree(unit string, edges map[string][]string) string {
+	if len(edges[unit]) == 0 {
+		return "  (none)\n"
+	}
+	s := strings.Builder{}
+	writeDependencyTree(&s, unit, edges, "  ", map[string]bool{unit: true})
+	return s.String()
+}
+
+func writeDependencyTree(s *strings.Builder, unit string, edges map[string][]string, prefix string, branch map[string]bool) {
+	children := edges[unit]
+	for i, child := range children {
+		connector, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		if branch[child] {
+			s.WriteString(prefix + connector + child + " (cycle)\n")
+			continue
+		}
+		s.WriteString(prefix + connector + child + "\n")
+		branch[child] = true
+		writeDependencyTree(s, child, edges, prefix+indent, branch)
+		delete(branch, child)
+	}
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/caiovfernandes/terragrunt-runner/history"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openHistory lists the recorded runs of item in a viewport that follows the
+// cursor.
+func (m *Model) openHistory(item Item) {
+	m.records, m.historyErr = m.history.List(item.path)
+	m.recordCursor = 0
+	m.historyViewPort = m.fullViewPort(4)
+	m.focused = runHistory
+}
+
+func (m *Model) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Back, m.keys.History):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Expand):
+		// Re-open the record in the output pane of the stack.
+		item, ok := m.list.SelectedItem().(Item)
+		if ok && len(m.records) > 0 {
+			record := m.records[m.recordCursor]
+			m.focused = main
+			return m, m.updateItem(item.path, func(item *Item) { item.lastExecution = recordMarkdown(record) })
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		m.recordCursor++
+		if m.recordCursor >= len(m.records) {
+			m.recordCursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		m.recordCursor--
+		if m.recordCursor < 0 {
+			m.recordCursor = len(m.records) - 1
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) historyView() string {
+	item, _ := m.list.SelectedItem().(Item)
+
+	var lines []string
+	switch {
+	case m.historyErr != nil:
+		lines = append(lines, m.historyErr.Error())
+	case len(m.records) == 0:
+		lines = append(lines, "No runs recorded yet.")
+	}
+	for i, record := range m.records {
+		cursor := "  "
+		if i == m.recordCursor {
+			cursor = "> "
+		}
+		lines = append(lines, cursor+recordSummary(record))
+	}
+	m.historyViewPort.SetContent(strings.Join(lines, "\n"))
+	followCursor(&m.historyViewPort, m.recordCursor, m.recordCursor)
+
+	header := headerStyle.Render(fmt.Sprintf("History of %s (%s)", item.title, item.description))
+	footer := fmt.Sprintf("\n(press %s to open a run, esc to go back)", m.keys.Expand.Help().Key)
+	return docStyle.Render(header + "\n\n" + m.historyViewPort.View() + footer)
+}
+
+func recordSummary(record history.Record) string {
+	sha := record.GitSHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return fmt.Sprintf("%s  %-10s %-10s exit %-3d %6s  %s  %s",
+		record.Start.Local().Format("2006-01-02 15:04:05"), record.Command, record.Status,
+		record.ExitCode, record.Duration().Round(100*time.Millisecond), record.User, sha)
+}
+
+func recordMarkdown(record history.Record) string {
+	s := fmt.Sprintf("# Run of `terragrunt %s`\n\n", strings.Join(record.Args, " "))
+	s += fmt.Sprintf("%s by %s", record.Start.Local().Format(time.RFC1123), record.User)
+	if record.GitSHA != "" {
+		s += fmt.Sprintf(" at `%s`", record.GitSHA)
+	}
+	s += fmt.Sprintf(", %s with exit code %d after %s.", record.Status, record.ExitCode, record.Duration().Round(time.Millisecond))
+	if record.Class != "" {
+		s += fmt.Sprintf(" Cause: **%s**.", record.Class)
+	}
+	s += "\n\n"
+	s += fmt.Sprintf("```shell\n%s\n```", record.Output)
+	if record.Error != "" {
+		s += fmt.Sprintf("\n\n**Error:** %s", record.Error)
+	}
+	return s
+}
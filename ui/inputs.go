@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	changedStyle = lipgloss.NewStyle().Foreground(driftColor)
+)
+
+// inputRow is a line of the inputs tree.
+type inputRow struct {
+	path   string
+	key    string
+	depth  int
+	value  interface{}
+	branch bool
+}
+
+// openInputs shows the inputs of the selected item in a viewport that follows
+// the cursor, leaving room for the search.
+func (m *Model) openInputs() {
+	m.inputsCursor = 0
+	m.inputsViewPort = m.fullViewPort(6)
+	m.inputSearch = textinput.New()
+	m.inputSearch.Prompt = "/"
+	m.focused = inputs
+}
+
+func (m *Model) updateInputs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.inputSearch.Focused() {
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			m.inputSearch.Blur()
+		case tea.KeyEsc:
+			m.inputSearch.Blur()
+			m.inputSearch.SetValue("")
+		default:
+			var cmd tea.Cmd
+			m.inputSearch, cmd = m.inputSearch.Update(msg)
+			m.inputsCursor = 0
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	rows := m.inputRows()
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Search):
+		m.inputSearch.Focus()
+		return m, textinput.Blink
+	case key.Matches(keyMsg, m.keys.Back, m.keys.Inputs):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Expand):
+		if len(rows) > 0 && m.inputsCursor < len(rows) && rows[m.inputsCursor].branch {
+			path := rows[m.inputsCursor].path
+			m.collapsed[path] = !m.collapsed[path]
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		m.inputsCursor++
+		if m.inputsCursor >= len(rows) {
+			m.inputsCursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		m.inputsCursor--
+		if m.inputsCursor < 0 {
+			m.inputsCursor = max(len(rows)-1, 0)
+		}
+	}
+	return m, nil
+}
+
+// inputRows lists the visible rows of the inputs tree of the selected item.
+// While searching, only the keys whose path matches and their parents are
+// listed, whether collapsed or not.
+func (m *Model) inputRows() []inputRow {
+	item, ok := m.list.SelectedItem().(Item)
+	if !ok {
+		return nil
+	}
+	query := strings.ToLower(m.inputSearch.Value())
+	var rows []inputRow
+	var walk func(path, key string, depth int, value interface{}) bool
+	walk = func(path, key string, depth int, value interface{}) bool {
+		row := inputRow{path: path, key: key, depth: depth, value: value}
+		matched := query == "" || strings.Contains(strings.ToLower(path), query)
+
+		index := len(rows)
+		rows = append(rows, row)
+		switch value := value.(type) {
+		case map[string]interface{}:
+			rows[index].branch = len(value) > 0
+			if m.collapsed[path] && query == "" {
+				break
+			}
+			for _, k := range terragrunt.SortedKeys(value) {
+				if walk(path+"."+k, k, depth+1, value[k]) {
+					matched = true
+				}
+			}
+		case []interface{}:
+			rows[index].branch = len(value) > 0
+			if m.collapsed[path] && query == "" {
+				break
+			}
+			for i, v := range value {
+				if walk(fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("[%d]", i), depth+1, v) {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			rows = rows[:index]
+		}
+		return matched
+	}
+	values := item.file.Inputs()
+	for _, k := range terragrunt.SortedKeys(values) {
+		walk(k, k, 0, values[k])
+	}
+	return rows
+}
+
+func (m *Model) inputsView() string {
+	item, _ := m.list.SelectedItem().(Item)
+	header := headerStyle.Render(fmt.Sprintf("Inputs of %s (%s)", item.title, item.description)) + "\n\n"
+	if m.inputSearch.Focused() || m.inputSearch.Value() != "" {
+		header += m.inputSearch.View() + "\n\n"
+	}
+
+	var lines []string
+	rows := m.inputRows()
+	if len(rows) == 0 {
+		lines = append(lines, "No inputs.")
+	}
+	for i, row := range rows {
+		cursor := "  "
+		if i == m.inputsCursor {
+			cursor = "> "
+		}
+		indent := strings.Repeat("  ", row.depth)
+		var line string
+		switch {
+		case row.branch && m.collapsed[row.path] && m.inputSearch.Value() == "":
+			line = fmt.Sprintf("▸ %s %s", row.key, summary(row.value))
+		case row.branch:
+			line = fmt.Sprintf("▾ %s", row.key)
+		default:
+			line = fmt.Sprintf("  %s = %s", row.key, terragrunt.FormatValue(row.value))
+		}
+		if row.depth == 0 && item.file.Effective != nil {
+			if setting, ok := item.file.Effective.Inputs[row.key]; ok {
+				line += dimStyle.Render("  (" + filepath.Base(setting.Origin) + ")")
+			}
+		}
+		lines = append(lines, cursor+indent+line)
+	}
+	m.inputsViewPort.SetContent(strings.Join(lines, "\n"))
+	followCursor(&m.inputsViewPort, m.inputsCursor, m.inputsCursor)
+
+	footer := fmt.Sprintf("\n(press %s to fold a key, / to search, esc to go back)", m.keys.Expand.Help().Key)
+	return docStyle.Render(header + m.inputsViewPort.View() + footer)
+}
+
+func summary(value interface{}) string {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("{%d}", len(value))
+	case []interface{}:
+		return fmt.Sprintf("[%d]", len(value))
+	}
+	return ""
+}
+
+// openCompare compares the inputs of the two selected items side by side.
+func (m *Model) openCompare() tea.Cmd {
+	var selected []Item
+	for _, listItem := range m.fullList.Items() {
+		if item := listItem.(Item); item.selected {
+			selected = append(selected, item)
+		}
+	}
+	if len(selected) != 2 {
+		return m.list.NewStatusMessage("Select two stacks with space to compare their inputs")
+	}
+	m.compared = [2]Item{selected[0], selected[1]}
+	width, height := 100, 27
+	if m.isWindowSizeSet() {
+		h, v := docStyle.GetFrameSize()
+		width, height = m.windowSize.Width-h, m.windowSize.Height-v-4
+	}
+	m.compareViewPort = viewport.New(width, height)
+	m.compareViewPort.SetContent(m.compareReport(width))
+	m.focused = compare
+	return nil
+}
+
+func (m *Model) updateCompare(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
+			return m, m.quit()
+		case key.Matches(keyMsg, m.keys.Back, m.keys.Compare):
+			m.focused = main
+			return m, nil
+		case key.Matches(keyMsg, m.keys.Select):
+			m.differencesOnly = !m.differencesOnly
+			m.compareViewPort.SetContent(m.compareReport(m.compareViewPort.Width))
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.compareViewPort, cmd = m.compareViewPort.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) compareView() string {
+	left, right := m.compared[0], m.compared[1]
+	header := headerStyle.Render(fmt.Sprintf("Inputs of %s vs %s", stackName(left), stackName(right)))
+	footer := "\n(press space to toggle unchanged keys, esc to go back)"
+	return docStyle.Render(header + "\n\n" + m.compareViewPort.View() + footer)
+}
+
+// compareReport renders the flattened inputs of both items in columns. Keys
+// only on the left are marked with -, keys only on the right with + and
+// differing values with ~.
+func (m *Model) compareReport(width int) string {
+	left := terragrunt.Flatten(m.compared[0].file.Inputs())
+	right := terragrunt.Flatten(m.compared[1].file.Inputs())
+	keys := make(map[string]bool)
+	keyWidth := len("KEY")
+	for _, values := range []map[string]interface{}{left, right} {
+		for key := range values {
+			keys[key] = true
+			if len(key) > keyWidth {
+				keyWidth = len(key)
+			}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	valueWidth := (width - keyWidth - 8) / 2
+	if valueWidth < 10 {
+		valueWidth = 10
+	}
+	format := fmt.Sprintf("%%s %%-%ds  %%-%ds  %%-%ds", keyWidth, valueWidth, valueWidth)
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf(format, " ", "KEY", truncate(stackName(m.compared[0]), valueWidth), truncate(stackName(m.compared[1]), valueWidth)) + "\n")
+	differences := 0
+	for _, key := range sorted {
+		l, inLeft := left[key]
+		r, inRight := right[key]
+		lText, rText := terragrunt.FormatValue(l), terragrunt.FormatValue(r)
+		marker, style := " ", dimStyle
+		switch {
+		case !inRight:
+			marker, style, rText = "-", removedStyle, ""
+		case !inLeft:
+			marker, style, lText = "+", addedStyle, ""
+		case lText != rText:
+			marker, style = "~", changedStyle
+		}
+		if marker != " " {
+			differences++
+		} else if m.differencesOnly {
+			continue
+		}
+		s.WriteString(style.Render(fmt.Sprintf(format, marker, key, truncate(lText, valueWidth), truncate(rText, valueWidth))) + "\n")
+	}
+	s.WriteString(fmt.Sprintf("\n%d of %d keys differ\n", differences, len(sorted)))
+	return s.String()
+}
+
+func stackName(item Item) string {
+	return item.file.ProjectID + "/" + item.file.RegionID + "/" + item.file.StackID
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the key bindings of every view. Their keys come from the
+// `keybindings` section of the configuration, keyed by the name passed to
+// binding below, which defaults to config.DefaultKeybindings.
+type keyMap struct {
+	Quit           key.Binding
+	Run            key.Binding
+	Cancel         key.Binding
+	Select         key.Binding
+	SelectProject  key.Binding
+	SelectRegion   key.Binding
+	ClearSelection key.Binding
+	Graph          key.Binding
+	Plan           key.Binding
+	Drift          key.Binding
+	History        key.Binding
+	Effective      key.Binding
+	Modules        key.Binding
+	Inputs         key.Binding
+	Compare        key.Binding
+	Search         key.Binding
+	Find           key.Binding
+	SaveFilter     key.Binding
+	Tree           key.Binding
+	Expand         key.Binding
+	Next           key.Binding
+	Left           key.Binding
+	Right          key.Binding
+	Up             key.Binding
+	Down           key.Binding
+	Back           key.Binding
+	Confirm        key.Binding
+}
+
+func newKeyMap(bindings map[string][]string) keyMap {
+	binding := func(name, help string) key.Binding {
+		keys := bindings[name]
+		return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(keys, "/"), help))
+	}
+	return keyMap{
+		Quit:           binding("quit", "quit"),
+		Run:            binding("run", "run command"),
+		Cancel:         binding("cancel", "cancel run"),
+		Select:         binding("select", "select"),
+		SelectProject:  binding("select_project", "select project"),
+		SelectRegion:   binding("select_region", "select region"),
+		ClearSelection: binding("clear_selection", "clear selection"),
+		Graph:          binding("graph", "dependencies"),
+		Plan:           binding("plan", "plan changes"),
+		Drift:          binding("drift", "drift sweep"),
+		History:        binding("history", "history"),
+		Effective:      binding("effective", "effective config"),
+		Modules:        binding("modules", "modules"),
+		Inputs:         binding("inputs", "inputs"),
+		Compare:        binding("compare", "compare inputs"),
+		Search:         binding("search", "search"),
+		Find:           binding("find", "search files"),
+		SaveFilter:     binding("save_filter", "save filter"),
+		Tree:           binding("tree", "tree"),
+		Expand:         binding("expand", "expand"),
+		Next:           binding("next", "filter"),
+		Left:           binding("left", "left"),
+		Right:          binding("right", "right"),
+		Up:             binding("up", "up"),
+		Down:           binding("down", "down"),
+		Back:           binding("back", "back"),
+		Confirm:        binding("confirm", "confirm"),
+	}
+}
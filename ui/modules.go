@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var versionStyles = map[string]lipgloss.Style{
+	terragrunt.VersionBehind:      lipgloss.NewStyle().Foreground(driftColor),
+	terragrunt.VersionUnpinned:    lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+	terragrunt.VersionUnversioned: lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+}
+
+// openModules shows the module inventory in a scrollable viewport sized to
+// the window.
+func (m *Model) openModules() {
+	width, height := 100, 27
+	if m.isWindowSizeSet() {
+		h, v := docStyle.GetFrameSize()
+		width, height = m.windowSize.Width-h, m.windowSize.Height-v-2
+	}
+	m.modulesViewPort = viewport.New(width, height)
+	m.modulesViewPort.SetContent(modulesReport(m.workspace.Modules()))
+	m.focused = inventory
+}
+
+func (m *Model) updateModules(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, m.keys.Quit):
+			return m, m.quit()
+		case key.Matches(keyMsg, m.keys.Back, m.keys.Modules):
+			m.focused = main
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.modulesViewPort, cmd = m.modulesViewPort.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) modulesView() string {
+	header := headerStyle.Render("Modules") + "\n\n"
+	footer := "\n(press esc to go back)"
+	return docStyle.Render(header + m.modulesViewPort.View() + footer)
+}
+
+func modulesReport(modules []terragrunt.Module) string {
+	if len(modules) == 0 {
+		return "No stack has a terraform source.\n"
+	}
+	s := strings.Builder{}
+	for _, module := range modules {
+		s.WriteString(headerStyle.Render(module.Source) + "\n")
+		var versions []string
+		for _, version := range module.Versions {
+			versions = append(versions, fmt.Sprintf("%s (%d)", refName(version.Ref), version.Stacks))
+		}
+		fmt.Fprintf(&s, "  latest %s, most common %s, pinned: %s\n", refName(module.Latest), refName(module.MostCommon), strings.Join(versions, ", "))
+		for _, stack := range module.Stacks {
+			line := fmt.Sprintf("  %-40s %-12s %s", stack.Stack, refName(stack.Ref), stack.Status)
+			if style, ok := versionStyles[stack.Status]; ok {
+				line = style.Render(line)
+			}
+			s.WriteString(line + "\n")
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+func refName(ref string) string {
+	if ref == "" {
+		return "-"
+	}
+	return ref
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var actionStyles = map[terragrunt.Action]lipgloss.Style{
+	terragrunt.ActionCreate:  lipgloss.NewStyle().Foreground(lipgloss.Color("42")),
+	terragrunt.ActionUpdate:  lipgloss.NewStyle().Foreground(lipgloss.Color("214")),
+	terragrunt.ActionReplace: lipgloss.NewStyle().Foreground(lipgloss.Color("170")),
+	terragrunt.ActionDelete:  lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
+}
+
+var actionSymbols = map[terragrunt.Action]string{
+	terragrunt.ActionCreate:  "+",
+	terragrunt.ActionUpdate:  "~",
+	terragrunt.ActionReplace: "±",
+	terragrunt.ActionDelete:  "-",
+}
+
+func (m *Model) selectedPlan() *terragrunt.PlanChanges {
+	if item, ok := m.list.SelectedItem().(Item); ok {
+		return item.plan
+	}
+	return nil
+}
+
+// openPlan shows the resource changes of the selected plan in a viewport
+// that follows the cursor.
+func (m *Model) openPlan() {
+	m.planCursor = 0
+	m.planViewPort = m.fullViewPort(4)
+	m.focused = changes
+}
+
+func (m *Model) updatePlan(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	plan := m.selectedPlan()
+	if plan == nil {
+		m.focused = main
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Back, m.keys.Plan):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Expand):
+		if len(plan.Resources) > 0 {
+			address := plan.Resources[m.planCursor].Address
+			m.expanded[address] = !m.expanded[address]
+		}
+	case key.Matches(keyMsg, m.keys.Down):
+		m.planCursor++
+		if m.planCursor >= len(plan.Resources) {
+			m.planCursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		m.planCursor--
+		if m.planCursor < 0 {
+			m.planCursor = len(plan.Resources) - 1
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) planView() string {
+	item, _ := m.list.SelectedItem().(Item)
+	plan := item.plan
+	if plan == nil {
+		return ""
+	}
+
+	var lines []string
+	if len(plan.Resources) == 0 {
+		lines = append(lines, "No changes. Your infrastructure matches the configuration.")
+	}
+	// The cursor follows the resource along with its attributes.
+	start, end := 0, 0
+	for i, resource := range plan.Resources {
+		cursor := "  "
+		if i == m.planCursor {
+			cursor = "> "
+			start = len(lines)
+		}
+		style := actionStyles[resource.Action]
+		lines = append(lines, cursor+style.Render(fmt.Sprintf("%s %s (%s)", actionSymbols[resource.Action], resource.Address, resource.Action)))
+		if m.expanded[resource.Address] {
+			if len(resource.Attributes) == 0 {
+				lines = append(lines, "      (no attribute changes)")
+			}
+			for _, attribute := range resource.Attributes {
+				lines = append(lines, fmt.Sprintf("      %s: %s → %s", attribute.Name, attribute.Before, attribute.After))
+			}
+		}
+		if i == m.planCursor {
+			end = len(lines) - 1
+		}
+	}
+	m.planViewPort.SetContent(strings.Join(lines, "\n"))
+	followCursor(&m.planViewPort, start, end)
+
+	header := headerStyle.Render(fmt.Sprintf("Plan of %s (%s): %s", item.title, item.description, plan.Badge()))
+	footer := fmt.Sprintf("\n(press %s to expand a resource, esc to go back)", m.keys.Expand.Help().Key)
+	return docStyle.Render(header + "\n\n" + m.planViewPort.View() + footer)
+}
@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/caiovfernandes/terragrunt-runner/history"
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandStartMsg is sent when a queued command is picked up by a worker.
+type commandStartMsg struct {
+	Path string
+}
+
+// commandOutputMsg carries a single line of output from a running command.
+type commandOutputMsg struct {
+	Path    string
+	Command terragrunt.Command
+	Line    string
+}
+
+// commandMsg is sent once a command has exited or was cancelled while queued.
+type commandMsg struct {
+	Path    string
+	Command terragrunt.Command
+	Status  terragrunt.Status
+	Result  terragrunt.Result
+	Plan    *terragrunt.PlanChanges
+	Error   error
+	// HistoryError is set when the run could not be added to the history.
+	HistoryError error
+}
+
+// waitForEvent blocks until a background run publishes a message and hands it
+// to the Bubble Tea loop. Handlers re-issue it to keep listening.
+func waitForEvent(events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// runCommand queues the command for every item and runs them in the
+// background through a worker pool bounded by m.config.Parallelism. Progress
+// and output are forwarded to the events channel.
+func (m *Model) runCommand(items []Item, command terragrunt.Command) tea.Cmd {
+	var jobs []terragrunt.Job
+	var cmds []tea.Cmd
+	for _, item := range items {
+		if _, ok := m.running[item.path]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		m.running[item.path] = cancel
+		jobs = append(jobs, terragrunt.Job{Path: item.path, Command: m.config.CommandFor(item.file.ProjectID, command), Context: ctx})
+
+		cmds = append(cmds, m.updateItem(item.path, func(item *Item) {
+			item.status = terragrunt.Queued
+			item.output = nil
+			item.lastExecution = fmt.Sprintf("Queued `%s`", command)
+		}))
+	}
+
+	// Drift plans change nothing, so they do not wait for their dependencies.
+	graph := m.graph
+	if command.Label == terragrunt.Drift.Label {
+		graph = nil
+	}
+
+	events, store := m.events, m.history
+	batch := terragrunt.Batch{
+		Jobs:        jobs,
+		Parallelism: m.config.Parallelism,
+		Graph:       graph,
+		OnStart: func(job terragrunt.Job) {
+			events <- commandStartMsg{Path: job.Path}
+		},
+		OnOutput: func(job terragrunt.Job, line string) {
+			events <- commandOutputMsg{Path: job.Path, Command: job.Command, Line: line}
+		},
+		OnFinish: func(result terragrunt.JobResult) {
+			var historyErr error
+			if !result.Started.IsZero() {
+				historyErr = store.Save(history.NewRecord(result))
+			}
+			events <- commandMsg{Path: result.Job.Path, Command: result.Job.Command, Status: result.Status, Result: result.Result, Plan: result.Plan, Error: result.Err, HistoryError: historyErr}
+		},
+	}
+	go batch.Run(context.Background())
+	return tea.Batch(cmds...)
+}
+
+// cancelCommand interrupts the run of the item backed by the file at path, if
+// there is one. The run reports back through a commandMsg once it has exited.
+func (m *Model) cancelCommand(path string) {
+	if cancel, ok := m.running[path]; ok {
+		cancel()
+	}
+}
+
+// quit interrupts every running command and leaves the program once they have
+// all reported back, so that no terragrunt process outlives the UI and its
+// state locks are released. Quitting again while waiting leaves at once.
+func (m *Model) quit() tea.Cmd {
+	if m.quitting || len(m.running) == 0 {
+		return tea.Quit
+	}
+	m.quitting = true
+	for _, cancel := range m.running {
+		cancel()
+	}
+	return nil
+}
+
+func (m *Model) quittingView() string {
+	return docStyle.Render(fmt.Sprintf("Cancelling %d running commands…\n\n(press %s again to quit without waiting)", len(m.running), m.keys.Quit.Help().Key))
+}
+
+// selectedItems returns the items marked for a batch run, or the item under the
+// cursor when nothing is marked.
+func (m *Model) selectedItems() []Item {
+	var items []Item
+	for _, listItem := range m.list.Items() {
+		if item := listItem.(Item); item.selected {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		if item, ok := m.list.SelectedItem().(Item); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// selectWhere marks every visible item matching fn for the next batch run.
+func (m *Model) selectWhere(fn func(item Item) bool) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, listItem := range m.list.Items() {
+		if item := listItem.(Item); fn(item) {
+			cmds = append(cmds, m.updateItem(item.path, func(item *Item) { item.selected = true }))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// driftSweep runs the drift plan on every visible item.
+func (m *Model) driftSweep() tea.Cmd {
+	var items []Item
+	for _, listItem := range m.list.Items() {
+		items = append(items, listItem.(Item))
+	}
+	return m.runCommand(items, terragrunt.Drift)
+}
+
+func (m *Model) clearSelection() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, listItem := range m.fullList.Items() {
+		if item := listItem.(Item); item.selected {
+			cmds = append(cmds, m.updateItem(item.path, func(item *Item) { item.selected = false }))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// itemIndex maps the paths of the items of l to their position, so that
+// updateItem finds them without scanning the list on every output line.
+func itemIndex(l list.Model) map[string]int {
+	index := make(map[string]int, len(l.Items()))
+	for i, listItem := range l.Items() {
+		index[listItem.(Item).path] = i
+	}
+	return index
+}
+
+// updateItem applies fn to the list item backed by the file at path, in both
+// the visible and the unfiltered list. The returned command refreshes the
+// results of the list filter.
+func (m *Model) updateItem(path string, fn func(item *Item)) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, l := range []struct {
+		list  *list.Model
+		index map[string]int
+	}{{&m.list, m.listIndex}, {&m.fullList, m.fullListIndex}} {
+		index, ok := l.index[path]
+		if !ok {
+			continue
+		}
+		item := l.list.Items()[index].(Item)
+		before := item
+		fn(&item)
+		cmds = append(cmds, l.list.SetItem(index, item))
+		if l.list == &m.list {
+			m.updateTreeCounts(before, item)
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func executionMarkdown(command terragrunt.Command, output string, err error) string {
+	s := fmt.Sprintf("# Output: `%s`\n\n```shell\n%s\n```", command, output)
+	if errors.Is(err, context.Canceled) {
+		s += "\n\n**Cancelled**"
+	} else if err != nil {
+		s += fmt.Sprintf("\n\n**Error:** %s", err)
+	}
+	return s
+}
+
+// resultMarkdown renders a finished run. Failures lead with their exit code,
+// known cause and the end of stderr, which usually explains them.
+func resultMarkdown(command terragrunt.Command, status terragrunt.Status, result terragrunt.Result, err error) string {
+	if status != terragrunt.Failed {
+		return executionMarkdown(command, result.Output, err)
+	}
+	s := fmt.Sprintf("# %s Failed: `%s`\n\n", statusIcons[status], command)
+	if result.ExitCode >= 0 {
+		s += fmt.Sprintf("Exit code %d after %s.", result.ExitCode, result.Duration.Round(time.Millisecond))
+	}
+	if result.Class != "" {
+		s += fmt.Sprintf(" Cause: **%s**.", result.Class)
+	}
+	s += fmt.Sprintf("\n\n**Error:** %s", err)
+	if result.StderrTail != "" {
+		s += fmt.Sprintf("\n\n## stderr\n\n```shell\n%s\n```", result.StderrTail)
+	}
+	s += fmt.Sprintf("\n\n## Output\n\n```shell\n%s\n```", result.Output)
+	return s
+}
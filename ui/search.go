@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const listTitle = "Terragrunt Files"
+
+var (
+	highlightStyle  = lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
+	lineNumberStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+func newFinder() textinput.Model {
+	finder := textinput.New()
+	finder.Prompt = "search: "
+	finder.Placeholder = "project:prod input.instance_type=t3.large source~vpc text"
+	return finder
+}
+
+func (m *Model) openFinder() tea.Cmd {
+	m.finder.SetValue(m.query)
+	m.finder.CursorEnd()
+	m.finder.Focus()
+	return textinput.Blink
+}
+
+func (m *Model) updateFinder(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.finder.Blur()
+		return m, m.search(m.finder.Value())
+	case tea.KeyEsc:
+		m.finder.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.finder, cmd = m.finder.Update(msg)
+	return m, cmd
+}
+
+// search lists the items matching query, with the lines to highlight in the
+// code pane. An empty query clears the search.
+func (m *Model) search(query string) tea.Cmd {
+	parsed, err := terragrunt.ParseQuery(query)
+	if err != nil {
+		return m.list.NewStatusMessage(err.Error())
+	}
+	m.query = strings.TrimSpace(query)
+	m.searchMatches = nil
+	if !parsed.Empty() {
+		m.searchMatches = make(map[string][]terragrunt.LineMatch)
+		for _, result := range m.workspace.Search(parsed) {
+			m.searchMatches[result.File.Path] = result.Matches
+		}
+	}
+	return m.refreshList()
+}
+
+// matchContext is the number of lines shown above the first match.
+const matchContext = 2
+
+func highlightedHeader(item Item) string {
+	return headerStyle.Render(item.path) + "\n\n"
+}
+
+// matchOffset is the offset of the code pane that shows the first match of
+// item below a few lines of context.
+func matchOffset(item Item) int {
+	header := lipgloss.Height(highlightedHeader(item)) - 1
+	return header + item.matches[0].Line - 1 - matchContext
+}
+
+// highlightedContent renders the file of item with line numbers, the matches
+// of the search highlighted.
+func highlightedContent(item Item) string {
+	spans := make(map[int][][2]int, len(item.matches))
+	for _, match := range item.matches {
+		spans[match.Line] = match.Spans
+	}
+	s := strings.Builder{}
+	s.WriteString(highlightedHeader(item))
+	for i, line := range strings.Split(item.file.Content(), "\n") {
+		s.WriteString(lineNumberStyle.Render(fmt.Sprintf("%4d ", i+1)))
+		offset := 0
+		for _, span := range spans[i+1] {
+			if span[1] > len(line) {
+				break
+			}
+			s.WriteString(line[offset:span[0]] + highlightStyle.Render(line[span[0]:span[1]]))
+			offset = span[1]
+		}
+		s.WriteString(line[offset:] + "\n")
+	}
+	return s.String()
+}
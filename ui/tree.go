@@ -0,0 +1,282 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Levels of the nodes of the tree view.
+const (
+	projectNode = iota
+	regionNode
+	stackNode
+)
+
+// treeNode is a line of the tree view. Project and region nodes hold every
+// listed stack underneath them.
+type treeNode struct {
+	id    string
+	level int
+	label string
+	// indices are the positions in the list of the stacks under the node.
+	indices  []int
+	children []*treeNode
+	counts   treeCounts
+}
+
+// treeCounts rolls up the state of the stacks under a node.
+type treeCounts struct {
+	statuses map[terragrunt.Status]int
+	drifted  int
+	selected int
+}
+
+// add counts item n times, or removes it when n is negative.
+func (c *treeCounts) add(item Item, n int) {
+	c.statuses[item.status] += n
+	if item.drift == terragrunt.Drifted {
+		c.drifted += n
+	}
+	if item.selected {
+		c.selected += n
+	}
+}
+
+// buildTree groups the listed items by project and region, in list order.
+// It runs whenever the items of the list are replaced, while updateItem
+// keeps the counts up to date in between.
+func (m *Model) buildTree() {
+	m.treeRoots = nil
+	m.treeAncestors = make(map[string][]*treeNode)
+	nodes := make(map[string]*treeNode)
+	add := func(parent *treeNode, id string, level int, label string) *treeNode {
+		if node, ok := nodes[id]; ok {
+			return node
+		}
+		node := &treeNode{id: id, level: level, label: label, counts: treeCounts{statuses: make(map[terragrunt.Status]int)}}
+		nodes[id] = node
+		if parent == nil {
+			m.treeRoots = append(m.treeRoots, node)
+		} else {
+			parent.children = append(parent.children, node)
+		}
+		return node
+	}
+	for index, listItem := range m.list.Items() {
+		item := listItem.(Item)
+		project := add(nil, item.file.ProjectID, projectNode, item.file.ProjectID)
+		region := add(project, project.id+"/"+item.file.RegionID, regionNode, item.file.RegionID)
+		stack := add(region, item.path, stackNode, item.title)
+		ancestors := []*treeNode{project, region, stack}
+		for _, node := range ancestors {
+			node.indices = append(node.indices, index)
+			node.counts.add(item, 1)
+		}
+		m.treeAncestors[item.path] = ancestors
+	}
+}
+
+// updateTreeCounts moves an item from its state before to its state after a
+// change in the counts of its nodes.
+func (m *Model) updateTreeCounts(before, after Item) {
+	for _, node := range m.treeAncestors[after.path] {
+		node.counts.add(before, -1)
+		node.counts.add(after, 1)
+	}
+}
+
+// treeNodes returns the nodes of the tree in order, leaving out the children
+// of collapsed nodes.
+func (m *Model) treeNodes() []*treeNode {
+	var nodes []*treeNode
+	var walk func(children []*treeNode)
+	walk = func(children []*treeNode) {
+		for _, node := range children {
+			nodes = append(nodes, node)
+			if !m.treeCollapsed[node.id] {
+				walk(node.children)
+			}
+		}
+	}
+	walk(m.treeRoots)
+	return nodes
+}
+
+// treeItems returns the stacks under node.
+func (m *Model) treeItems(node *treeNode) []Item {
+	listItems := m.list.Items()
+	items := make([]Item, len(node.indices))
+	for i, index := range node.indices {
+		items[i] = listItems[index].(Item)
+	}
+	return items
+}
+
+// openTree shows the listed stacks as a tree, in a viewport that follows the
+// cursor.
+func (m *Model) openTree() {
+	m.treeCursor = 0
+	m.treeViewPort = m.fullViewPort(4)
+	if item, ok := m.list.SelectedItem().(Item); ok {
+		for i, node := range m.treeNodes() {
+			if node.id == item.path {
+				m.treeCursor = i
+			}
+		}
+	}
+	m.focused = tree
+}
+
+func (m *Model) updateTree(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	nodes := m.treeNodes()
+	if len(nodes) == 0 {
+		if key.Matches(keyMsg, m.keys.Quit) {
+			return m, m.quit()
+		}
+		m.focused = main
+		return m, nil
+	}
+	m.treeCursor = min(m.treeCursor, len(nodes)-1)
+	node := nodes[m.treeCursor]
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, m.quit()
+	case key.Matches(keyMsg, m.keys.Back, m.keys.Tree):
+		m.focused = main
+	case key.Matches(keyMsg, m.keys.Run):
+		// Run the command picker on every stack of the node.
+		cmds := []tea.Cmd{m.clearSelection()}
+		for _, item := range m.treeItems(node) {
+			cmds = append(cmds, m.updateItem(item.path, func(item *Item) { item.selected = true }))
+		}
+		m.focused = commands
+		m.commandCursor = 0
+		m.confirming = false
+		return m, tea.Batch(cmds...)
+	case key.Matches(keyMsg, m.keys.Select):
+		selected := node.counts.selected == len(node.indices)
+		var cmds []tea.Cmd
+		for _, item := range m.treeItems(node) {
+			cmds = append(cmds, m.updateItem(item.path, func(item *Item) { item.selected = !selected }))
+		}
+		return m, tea.Batch(cmds...)
+	case key.Matches(keyMsg, m.keys.Cancel):
+		for _, item := range m.treeItems(node) {
+			m.cancelCommand(item.path)
+		}
+	case key.Matches(keyMsg, m.keys.Left):
+		if node.level < stackNode && !m.treeCollapsed[node.id] {
+			m.treeCollapsed[node.id] = true
+			break
+		}
+		// Move up to the parent.
+		for i := m.treeCursor - 1; i >= 0; i-- {
+			if nodes[i].level < node.level {
+				m.treeCursor = i
+				break
+			}
+		}
+	case key.Matches(keyMsg, m.keys.Right):
+		delete(m.treeCollapsed, node.id)
+	case key.Matches(keyMsg, m.keys.Down):
+		m.treeCursor++
+		if m.treeCursor >= len(nodes) {
+			m.treeCursor = 0
+		}
+	case key.Matches(keyMsg, m.keys.Up):
+		m.treeCursor--
+		if m.treeCursor < 0 {
+			m.treeCursor = len(nodes) - 1
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) treeView() string {
+	nodes := m.treeNodes()
+	if len(nodes) == 0 {
+		return docStyle.Render("No stacks to show.\n\n(press esc to go back)")
+	}
+	m.treeCursor = min(m.treeCursor, len(nodes)-1)
+
+	var lines []string
+	for i, node := range nodes {
+		cursor := "  "
+		if i == m.treeCursor {
+			cursor = "> "
+		}
+		indent := strings.Repeat("  ", node.level)
+		var line string
+		if node.level == stackNode {
+			item := m.list.Items()[node.indices[0]].(Item)
+			line = "  " + item.Title()
+			if item.drift != "" {
+				line += dimStyle.Render(fmt.Sprintf(" (%s)", item.drift))
+			}
+		} else {
+			fold := "▾"
+			if m.treeCollapsed[node.id] {
+				fold = "▸"
+			}
+			line = fmt.Sprintf("%s %s%s %s", fold, selectionMark(node), node.label, rollup(node))
+		}
+		lines = append(lines, cursor+indent+line)
+	}
+	content := strings.Join(lines, "\n")
+	// The tree is only as wide as its lines, leaving the rest to the panes.
+	m.treeViewPort.Width = lipgloss.Width(content)
+	m.treeViewPort.SetContent(content)
+	followCursor(&m.treeViewPort, m.treeCursor, m.treeCursor)
+
+	header := headerStyle.Render("Workspace")
+	footer := "\n(press space to select, enter to run, ←/→ to fold, esc to go back)"
+
+	// The panes show the stack under the cursor, or the first stack of a
+	// project or region.
+	return lipgloss.JoinHorizontal(
+		lipgloss.Left,
+		docStyle.Render(header+"\n\n"+m.treeViewPort.View()+footer),
+		m.panesView(m.list.Items()[nodes[m.treeCursor].indices[0]].(Item)),
+	)
+}
+
+// selectionMark tells whether all or some of the stacks of node are selected.
+func selectionMark(node *treeNode) string {
+	switch {
+	case node.counts.selected == 0:
+		return ""
+	case node.counts.selected == len(node.indices):
+		return "[x] "
+	}
+	return "[-] "
+}
+
+// rollup counts the stacks of node and their statuses, e.g.
+// "4 stacks ✔ 2 ✖ 1 1 drifted".
+func rollup(node *treeNode) string {
+	noun := "stacks"
+	if len(node.indices) == 1 {
+		noun = "stack"
+	}
+	parts := []string{fmt.Sprintf("%d %s", len(node.indices), noun)}
+	for status := terragrunt.Queued; status <= terragrunt.Cancelled; status++ {
+		if count := node.counts.statuses[status]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", statusIcons[status], count))
+		}
+	}
+	text := dimStyle.Render(strings.Join(parts, " "))
+	if node.counts.drifted > 0 {
+		text += lipgloss.NewStyle().Foreground(driftColor).Render(fmt.Sprintf(" %d drifted", node.counts.drifted))
+	}
+	return text
+}
@@ -1,12 +1,17 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/caiovfernandes/terragrunt-runner/config"
+	"github.com/caiovfernandes/terragrunt-runner/history"
 	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
@@ -31,27 +36,73 @@ type (
 const (
 	main views = iota
 	filter
+	commands
+	dependencies
+	changes
+	runHistory
+	inventory
+	inputs
+	compare
+	tree
 )
 
-type Filter struct {
-	region  string
-	stack   string
-	project string
-}
-
 type Item struct {
 	title         string
 	description   string
 	path          string
-	content       string
 	lastExecution string
+	status        terragrunt.Status
+	result        terragrunt.Result
+	plan          *terragrunt.PlanChanges
+	drift         terragrunt.DriftStatus
+	selected      bool
 	cursor        int
 	choice        string
 	file          terragrunt.File
+	// matches are the lines of the file matching the current search.
+	matches []terragrunt.LineMatch
+	// output holds the lines of the running command, shown as they are
+	// until it exits and lastExecution renders the result.
+	output  []string
+	command terragrunt.Command
+}
+
+var statusIcons = map[terragrunt.Status]string{
+	terragrunt.Queued:    "…",
+	terragrunt.Running:   "⟳",
+	terragrunt.Succeeded: "✔",
+	terragrunt.Failed:    "✖",
+	terragrunt.Cancelled: "⊘",
+}
+
+func (i Item) Title() string {
+	title := i.title
+	if icon, ok := statusIcons[i.status]; ok {
+		title = icon + " " + title
+	}
+	if i.selected {
+		return "[x] " + title
+	}
+	return title
+}
+
+func (i Item) Description() string {
+	description := i.description
+	switch {
+	case i.result.Class != "":
+		description = fmt.Sprintf("%s [%s: %s]", description, i.status, i.result.Class)
+	case i.status != 0:
+		description = fmt.Sprintf("%s [%s]", description, i.status)
+	}
+	if i.plan != nil {
+		description = fmt.Sprintf("%s %s", description, i.plan.Badge())
+	}
+	if i.drift != "" {
+		description = fmt.Sprintf("%s (%s)", description, i.drift)
+	}
+	return description
 }
 
-func (i Item) Title() string       { return i.title }
-func (i Item) Description() string { return i.description }
 func (i Item) FilterValue() string { return i.title }
 
 type Model struct {
@@ -60,48 +111,198 @@ type Model struct {
 	codeViewPort     viewport.Model
 	tfViewPort       viewport.Model
 	viewportRenderer *glamour.TermRenderer
-	planView         bool
 	focused          views
-	cursor           int
 	workspace        terragrunt.Workspace
-	regions          []string
-	projects         []string
-	stacks           []string
+	graph            *terragrunt.Graph
+	commandCursor    int
+	confirming       bool
+	events           chan tea.Msg
+	running          map[string]context.CancelFunc
+	config           config.Config
+	commands         []terragrunt.Command
+	keys             keyMap
+	planCursor       int
+	planViewPort     viewport.Model
+	expanded         map[string]bool
+	history          *history.Store
+	records          []history.Record
+	recordCursor     int
+	historyViewPort  viewport.Model
+	historyErr       error
+	showEffective    bool
+	modulesViewPort  viewport.Model
+	inputsCursor     int
+	inputsViewPort   viewport.Model
+	collapsed        map[string]bool
+	inputSearch      textinput.Model
+	compared         [2]Item
+	compareViewPort  viewport.Model
+	differencesOnly  bool
+	finder           textinput.Model
+	query            string
+	searchMatches    map[string][]terragrunt.LineMatch
+	filter           config.Filter
+	draft            config.Filter
+	filterColumn     int
+	filterCursors    [filterColumns]int
+	filterName       textinput.Model
+	filterMessage    string
+	treeCursor       int
+	treeCollapsed    map[string]bool
+	listIndex        map[string]int
+	fullListIndex    map[string]int
+	treeRoots        []*treeNode
+	treeAncestors    map[string][]*treeNode
+	treeViewPort     viewport.Model
+	rendered         map[string]string
+	// quitting is set once the user quit while commands were running.
+	quitting bool
 
 	windowSize tea.WindowSizeMsg
 }
 
 func (m *Model) Init() tea.Cmd {
 	m.list = m.fullList
-	return nil
+	// The lists start out sharing their items, which updateItem would then
+	// change twice.
+	cmd := m.list.SetItems(append([]list.Item(nil), m.fullList.Items()...))
+	m.listIndex, m.fullListIndex = itemIndex(m.list), itemIndex(m.fullList)
+	m.buildTree()
+	return tea.Batch(cmd, waitForEvent(m.events))
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandStartMsg:
+		cmd := m.updateItem(msg.Path, func(item *Item) { item.status = terragrunt.Running })
+		return m, tea.Batch(cmd, waitForEvent(m.events))
+	case commandOutputMsg:
+		cmd := m.updateItem(msg.Path, func(item *Item) {
+			item.output = append(item.output, msg.Line)
+			item.command = msg.Command
+		})
+		return m, tea.Batch(cmd, waitForEvent(m.events))
+	case commandMsg:
+		// Release the context of the run, which may not have been cancelled.
+		if cancel, ok := m.running[msg.Path]; ok {
+			cancel()
+		}
+		delete(m.running, msg.Path)
+		if m.quitting && len(m.running) == 0 {
+			return m, tea.Quit
+		}
+		cmd := m.updateItem(msg.Path, func(item *Item) {
+			item.status = msg.Status
+			item.result = msg.Result
+			item.output = nil
+			if msg.Command.Name == terragrunt.Plan.Name {
+				item.plan = msg.Plan
+			}
+			if msg.Command.Label == terragrunt.Drift.Label && msg.Status != terragrunt.Cancelled {
+				item.drift = terragrunt.ClassifyDrift(msg.Plan, msg.Error)
+			}
+			item.lastExecution = resultMarkdown(msg.Command, msg.Status, msg.Result, msg.Error)
+			if msg.HistoryError != nil {
+				item.lastExecution += fmt.Sprintf("\n\n**History:** %s", msg.HistoryError)
+			}
+		})
+		return m, tea.Batch(cmd, waitForEvent(m.events))
+	case workspaceMsg:
+		return m, tea.Batch(m.applyChanges(msg.Changes), waitForEvent(m.events))
+	case watchErrorMsg:
+		return m, tea.Batch(m.list.NewStatusMessage("Watching the workspace: "+msg.Error.Error()), waitForEvent(m.events))
+	}
+
+	if m.quitting {
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Quit) {
+			return m, m.quit()
+		}
+		return m, nil
+	}
+
 	switch m.focused {
 	case main:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "ctrl+c":
-				return m, tea.Quit
-			case "enter":
-				currentItem := m.list.SelectedItem()
-				item := currentItem.(Item)
-				item.lastExecution = "Running terraform"
-				m.list.SetItem(m.list.Index(), item)
-				return m, runTerraformInit(item, m.list.Index())
-			case "n":
-				m.next()
-			case "down", "j":
-				m.cursor++
-				if m.cursor >= len(m.regions) {
-					m.cursor = 0
+			if key.Matches(msg, m.keys.Quit) {
+				return m, m.quit()
+			}
+			if m.list.FilterState() == list.Filtering {
+				break
+			}
+			if m.finder.Focused() {
+				return m.updateFinder(msg)
+			}
+			switch {
+			case key.Matches(msg, m.keys.Find):
+				return m, m.openFinder()
+			case key.Matches(msg, m.keys.Run):
+				if m.list.SelectedItem() != nil {
+					m.focused = commands
+					m.commandCursor = 0
+					m.confirming = false
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Cancel):
+				if item, ok := m.list.SelectedItem().(Item); ok {
+					m.cancelCommand(item.path)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Select):
+				if item, ok := m.list.SelectedItem().(Item); ok {
+					return m, m.updateItem(item.path, func(item *Item) { item.selected = !item.selected })
 				}
-			case "up", "k":
-				m.cursor--
-				if m.cursor < 0 {
-					m.cursor = len(m.regions) - 1
+				return m, nil
+			case key.Matches(msg, m.keys.SelectProject):
+				if current, ok := m.list.SelectedItem().(Item); ok {
+					return m, m.selectWhere(func(item Item) bool { return item.file.ProjectID == current.file.ProjectID })
 				}
+				return m, nil
+			case key.Matches(msg, m.keys.SelectRegion):
+				if current, ok := m.list.SelectedItem().(Item); ok {
+					return m, m.selectWhere(func(item Item) bool {
+						return item.file.ProjectID == current.file.ProjectID && item.file.RegionID == current.file.RegionID
+					})
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.ClearSelection):
+				return m, m.clearSelection()
+			case key.Matches(msg, m.keys.Graph):
+				if m.list.SelectedItem() != nil {
+					m.focused = dependencies
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Plan):
+				if m.selectedPlan() != nil {
+					m.openPlan()
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Drift):
+				return m, m.driftSweep()
+			case key.Matches(msg, m.keys.Inputs):
+				if m.list.SelectedItem() != nil {
+					m.openInputs()
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Compare):
+				return m, m.openCompare()
+			case key.Matches(msg, m.keys.Modules):
+				m.openModules()
+				return m, nil
+			case key.Matches(msg, m.keys.Effective):
+				m.showEffective = !m.showEffective
+				return m, nil
+			case key.Matches(msg, m.keys.History):
+				if item, ok := m.list.SelectedItem().(Item); ok {
+					m.openHistory(item)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Next):
+				m.openFilter()
+				return m, nil
+			case key.Matches(msg, m.keys.Tree):
+				m.openTree()
+				return m, nil
 			}
 		case tea.WindowSizeMsg:
 			h, _ := docStyle.GetFrameSize()
@@ -109,38 +310,25 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.list.SetSize(msg.Width, msg.Height)
 			m.codeViewPort.Height = msg.Height - h
 			m.tfViewPort.Height = msg.Height - h
-		case terraformInitMsg:
-			item := m.list.Items()[msg.Index].(Item)
-			item.lastExecution = "# Output:\n\n```shell" + msg.Output + "\n```" // Assuming we add a method to set this value
-			m.list.SetItem(msg.Index, item)
-			m.tfViewPort.GotoBottom()
 		}
 	case filter:
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			switch msg.String() {
-			case "ctrl+c":
-				return m, tea.Quit
-			case "enter":
-				filterCriteria := Filter{
-					region: m.regions[m.cursor],
-				}
-				m.UpdateListItems(filterCriteria)
-				m.focused = main
-			case "n":
-				m.next()
-			case "down", "j":
-				m.cursor++
-				if m.cursor >= len(m.regions) {
-					m.cursor = 0
-				}
-			case "up", "k":
-				m.cursor--
-				if m.cursor < 0 {
-					m.cursor = len(m.regions) - 1
-				}
-			}
-		}
+		return m.updateFilter(msg)
+	case commands:
+		return m.updateCommands(msg)
+	case dependencies:
+		return m.updateDependencies(msg)
+	case changes:
+		return m.updatePlan(msg)
+	case runHistory:
+		return m.updateHistory(msg)
+	case inventory:
+		return m.updateModules(msg)
+	case inputs:
+		return m.updateInputs(msg)
+	case compare:
+		return m.updateCompare(msg)
+	case tree:
+		return m.updateTree(msg)
 	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
@@ -148,78 +336,142 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
+	if m.quitting {
+		return m.quittingView()
+	}
 	if m.focused == filter {
-		s := strings.Builder{}
-		s.WriteString("Account filter\n\n")
-		for i, region := range m.regions {
-			if m.cursor == i {
-				s.WriteString("(•) ")
-			} else {
-				s.WriteString("( ) ")
-			}
-			s.WriteString(region)
-			s.WriteString("\n")
-		}
-		s.WriteString("\n(press q to quit)\n")
-
-		return lipgloss.PlaceHorizontal(50, lipgloss.Center, s.String())
+		return m.filterView()
+	}
+	if m.focused == commands {
+		return m.commandsView()
+	}
+	if m.focused == dependencies {
+		return m.dependenciesView()
+	}
+	if m.focused == changes {
+		return m.planView()
+	}
+	if m.focused == runHistory {
+		return m.historyView()
+	}
+	if m.focused == inventory {
+		return m.modulesView()
+	}
+	if m.focused == inputs {
+		return m.inputsView()
+	}
+	if m.focused == compare {
+		return m.compareView()
+	}
+	if m.focused == tree {
+		return m.treeView()
 	}
 	if m.focused == main {
+		finder := ""
+		if m.finder.Focused() || m.query != "" {
+			finder = m.finder.View() + "\n"
+		}
 		if m.isWindowSizeSet() {
-			m.list.SetSize(m.windowSize.Width, m.windowSize.Height)
+			m.list.SetSize(m.windowSize.Width, m.windowSize.Height-lipgloss.Height(finder)+1)
 		}
 
-		currentItem := m.list.SelectedItem()
-		var codeStr string
-		var err error
-		codeStr, err = m.viewportRenderer.Render(currentItem.(Item).content)
-
-		tfRunStr, err := m.viewportRenderer.Render(currentItem.(Item).lastExecution)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		currentItem, ok := m.list.SelectedItem().(Item)
+		if !ok {
+			return lipgloss.JoinVertical(lipgloss.Left, finder, m.list.View())
 		}
-		m.codeViewPort.SetContent(codeStr)
-		m.tfViewPort.SetContent(tfRunStr)
-		m.tfViewPort.GotoBottom()
 		return lipgloss.JoinHorizontal(
 			lipgloss.Left,
-			m.list.View(),
-			m.codeViewPort.View(),
-			m.tfViewPort.View(),
+			lipgloss.JoinVertical(lipgloss.Left, finder, m.list.View()),
+			m.panesView(currentItem),
 		)
 	}
 	return ""
 }
 
-func (m *Model) UpdateListItems(filterCriteria Filter) {
-	m.list = m.fullList
-	var filteredItems []list.Item
-
-	if filterCriteria.region != "All" {
-		for _, item := range m.list.Items() {
-			i := item.(Item)
-			// if strings.Contains(i.description, filterCriteria.project) || strings.Contains(i.description, filterCriteria.region) || strings.Contains(i.title, filterCriteria.stack) {
-			if strings.Contains(i.description, filterCriteria.region) {
-				filteredItems = append(filteredItems, i)
-			}
-		}
-		m.list.SetItems(filteredItems)
+// contentMarkdown renders the content of file, which is only read once the
+// file is shown.
+func contentMarkdown(file terragrunt.File) string {
+	return fmt.Sprintf("# `%s`\n", file.Path) + "\n```terraform\n" + file.Content() + "\n```"
+}
+
+// renderCacheSize bounds the rendered markdown kept by render.
+const renderCacheSize = 64
+
+// render renders markdown for a pane. Panes are drawn on every frame, so the
+// renders are kept until the cache fills up.
+func (m *Model) render(markdown string) string {
+	if rendered, ok := m.rendered[markdown]; ok {
+		return rendered
 	}
+	rendered, err := m.viewportRenderer.Render(markdown)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(m.rendered) >= renderCacheSize {
+		clear(m.rendered)
+	}
+	m.rendered[markdown] = rendered
+	return rendered
 }
 
-func (m *Model) next() {
-	if m.focused == filter {
-		m.focused = main
-	} else {
-		m.focused++
+// runningOutput shows the output of the command running on item as it is,
+// since rendering it again on every line would slow long runs down.
+func runningOutput(item Item) string {
+	return headerStyle.Render(fmt.Sprintf("$ %s", item.command)) + "\n\n" + strings.Join(item.output, "\n")
+}
+
+// panesView renders the code and execution panes of item.
+func (m *Model) panesView(item Item) string {
+	var codeStr string
+	switch {
+	case m.showEffective:
+		codeStr = m.render(m.effectiveMarkdown(item))
+	case len(item.matches) > 0:
+		codeStr = highlightedContent(item)
+	default:
+		codeStr = m.render(contentMarkdown(item.file))
 	}
+
+	tfRunStr := m.render(item.lastExecution)
+	if item.status == terragrunt.Running && len(item.output) > 0 {
+		tfRunStr = runningOutput(item)
+	}
+	m.codeViewPort.SetContent(codeStr)
+	if len(item.matches) > 0 && !m.showEffective {
+		m.codeViewPort.SetYOffset(matchOffset(item))
+	}
+	m.tfViewPort.SetContent(tfRunStr)
+	m.tfViewPort.GotoBottom()
+	return lipgloss.JoinHorizontal(lipgloss.Left, m.codeViewPort.View(), m.tfViewPort.View())
 }
 
 func (m *Model) isWindowSizeSet() bool {
 	return m.windowSize.Width != 0 && m.windowSize.Height != 0
 }
 
+// fullViewPort returns a viewport sized to the window, leaving lines for the
+// header and footer of the view.
+func (m *Model) fullViewPort(lines int) viewport.Model {
+	width, height := 100, 27
+	if m.isWindowSizeSet() {
+		h, v := docStyle.GetFrameSize()
+		width, height = m.windowSize.Width-h, m.windowSize.Height-v-lines
+	}
+	return viewport.New(width, height)
+}
+
+// followCursor scrolls vp as little as possible to show the lines from start
+// to end, preferring start when they do not fit.
+func followCursor(vp *viewport.Model, start, end int) {
+	if end >= vp.YOffset+vp.Height {
+		vp.SetYOffset(end - vp.Height + 1)
+	}
+	if start < vp.YOffset {
+		vp.SetYOffset(start)
+	}
+}
+
 func newDefaultViewPort() (viewport.Model, *glamour.TermRenderer, error) {
 	vp := viewport.New(100, 27)
 	vp.Style = lipgloss.NewStyle().
@@ -244,84 +496,59 @@ func newDefaultViewPort() (viewport.Model, *glamour.TermRenderer, error) {
 	return vp, renderer, nil
 }
 
-func Start() {
-	workspace, err := terragrunt.GetWorkspace()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-
+// Start runs the interactive UI over workspace until the user quits. The
+// workspace is watched meanwhile, so that the files added, modified or removed
+// are shown without restarting.
+func Start(cfg config.Config, workspace terragrunt.Workspace) error {
 	var items []list.Item
-	for projectName, project := range workspace.Projects {
-		for regionName, region := range project.Regions {
-			for stackName, stack := range region.Stacks {
-				for _, file := range stack.Files {
-					items = append(items, Item{
-						title:         stackName,
-						description:   fmt.Sprintf("Project: %s, Region: %s", projectName, regionName),
-						content:       fmt.Sprintf("# `%s`\n", file.Path) + "\n```terraform\n" + file.Content + "\n```",
-						path:          file.Path,
-						lastExecution: "# No execution yet",
-					})
-				}
-			}
-		}
+	for _, file := range workspace.Files() {
+		items = append(items, newItem(file))
 	}
 	viewPortModel, renderer, err := newDefaultViewPort()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
+	}
+	store, err := history.Open(cfg.History.Dir)
+	if err != nil {
+		return err
 	}
 	m := Model{
-		fullList:         list.New(items, list.NewDefaultDelegate(), 0, 0),
+		fullList:         list.New(items, newItemDelegate(), 0, 0),
 		codeViewPort:     viewPortModel,
 		viewportRenderer: renderer,
 		tfViewPort:       viewPortModel,
 		workspace:        workspace,
-		regions:          append(workspace.GetRegions(), "All"),
-		projects:         append(workspace.GetProjects(), "All"),
-		stacks:           append(workspace.GetStacks(), "All"),
+		graph:            workspace.Graph(),
+		events:           make(chan tea.Msg),
+		running:          make(map[string]context.CancelFunc),
+		config:           cfg,
+		commands:         cfg.Commands(),
+		keys:             newKeyMap(cfg.Keybindings),
+		expanded:         make(map[string]bool),
+		collapsed:        make(map[string]bool),
+		treeCollapsed:    make(map[string]bool),
+		history:          store,
+		finder:           newFinder(),
+		rendered:         make(map[string]string),
 	}
 
-	m.list.Title = "Terragrunt Files"
-	fmt.Println(m.stacks)
+	m.fullList.Title = listTitle
+	// Every exit goes through quit, which cancels the running commands.
+	m.fullList.DisableQuitKeybindings()
 	p := tea.NewProgram(&m, tea.WithAltScreen())
 
-	if _, err := p.Run(); err != nil {
-		fmt.Println("Error running program:", err)
-		os.Exit(1)
-	}
-}
-
-type terraformInitMsg struct {
-	Output string
-	Item   Item
-	Index  int
-	Error  error
-}
-
-func runTerraformInit(item Item, itemPosition int) tea.Cmd {
-	return func() tea.Msg {
-		output, err := terragrunt.RunTerraformInit(item.path)
-
-		saveStringToFile(output)
-		return terraformInitMsg{Output: output, Item: item, Index: itemPosition, Error: err}
-	}
-}
-
-func saveStringToFile(content string) {
-	// Create or open the file for writing
-	file, err := os.Create("output.txt")
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The UI works without watching, e.g. when the system runs out of
+	// watches, but tells why the workspace is not refreshed.
+	if watcher, err := terragrunt.Watch(ctx, workspace, cfg.LoadOptions()...); err != nil {
+		go func() { m.events <- watchErrorMsg{Error: err} }()
+	} else {
+		go watch(ctx, watcher, m.events)
 	}
-	defer file.Close()
 
-	// Write the content to the file
-	_, err = file.WriteString(content)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running program: %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/caiovfernandes/terragrunt-runner/terragrunt"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// workspaceMsg carries the changes to the terragrunt files made while the UI
+// is open.
+type workspaceMsg struct {
+	Changes []terragrunt.Change
+}
+
+// watchErrorMsg is sent when the workspace cannot be watched, or events were
+// lost.
+type watchErrorMsg struct {
+	Error error
+}
+
+// watch forwards the changes reported by watcher to the events channel until
+// ctx is cancelled.
+func watch(ctx context.Context, watcher *terragrunt.Watcher, events chan tea.Msg) {
+	for {
+		var msg tea.Msg
+		select {
+		case <-ctx.Done():
+			return
+		case changes, ok := <-watcher.Changes:
+			if !ok {
+				return
+			}
+			msg = workspaceMsg{Changes: changes}
+		case err := <-watcher.Errors:
+			msg = watchErrorMsg{Error: err}
+		}
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func newItem(file terragrunt.File) Item {
+	return Item{
+		title:         file.StackID,
+		description:   fmt.Sprintf("Project: %s, Region: %s", file.ProjectID, file.RegionID),
+		path:          file.Path,
+		file:          file,
+		lastExecution: "# No execution yet",
+	}
+}
+
+// applyChanges updates the workspace and the items with changes. Items keep
+// their selection and execution state, and the list keeps its cursor on the
+// same stack when it is still listed.
+func (m *Model) applyChanges(changes []terragrunt.Change) tea.Cmd {
+	m.workspace.Apply(changes)
+	m.graph = m.workspace.Graph()
+
+	existing := make(map[string]Item, len(m.fullList.Items()))
+	for _, listItem := range m.fullList.Items() {
+		item := listItem.(Item)
+		existing[item.path] = item
+	}
+	var items []list.Item
+	for _, file := range m.workspace.Files() {
+		item, ok := existing[file.Path]
+		if !ok {
+			item = newItem(file)
+		}
+		item.file = file
+		items = append(items, item)
+	}
+
+	current, _ := m.list.SelectedItem().(Item)
+	cmds := []tea.Cmd{m.fullList.SetItems(items)}
+	m.fullListIndex = itemIndex(m.fullList)
+	if m.query != "" {
+		cmds = append(cmds, m.search(m.query))
+	} else {
+		cmds = append(cmds, m.refreshList())
+	}
+	for index, listItem := range m.list.Items() {
+		if listItem.(Item).path == current.path {
+			m.list.Select(index)
+			break
+		}
+	}
+	return tea.Batch(append(cmds, m.list.NewStatusMessage(changesSummary(changes)))...)
+}
+
+// changesSummary counts changes by kind, e.g. "1 added, 2 modified".
+func changesSummary(changes []terragrunt.Change) string {
+	counts := make(map[terragrunt.ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	var parts []string
+	for _, kind := range []terragrunt.ChangeKind{terragrunt.Added, terragrunt.Modified, terragrunt.Removed} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return "Workspace changed: " + strings.Join(parts, ", ")
+}
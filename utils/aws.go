@@ -7,13 +7,19 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 )
 
-func GetAwsCredentials() (string, string, string, error) {
-	region := os.Getenv("AWS_REGION")
+// GetAwsCredentials retrieves credentials for the given profile and region.
+// Empty values fall back to AWS_PROFILE and AWS_REGION.
+func GetAwsCredentials(profile, region string) (string, string, string, error) {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
 	if region == "" {
 		region = "us-east-2" // Default region if not set
 	}
 
-	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
 	if profile == "" {
 		profile = "default" // Default profile if not set
 	}